@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/apermo/apermo-surf/internal/config"
+	"github.com/apermo/apermo-surf/internal/export"
+	"github.com/apermo/apermo-surf/internal/resolve"
+	"github.com/apermo/apermo-surf/internal/userconfig"
+	"github.com/spf13/cobra"
+)
+
+var outputFlag string
+
+var addToChromeCmd = &cobra.Command{
+	Use:   "add-to-chrome",
+	Short: "Export project links for the Chrome extension",
+	Long: `Export the merged project config (including generated and sub-links) in the
+shared format read by apermo-surf-chrome, and report which links changed
+since the last export.
+
+By default the export is written to ~/.config/surf/chrome/<project>.json.
+Use -o - to print it to stdout instead.`,
+	Args: cobra.NoArgs,
+	RunE: runAddToChrome,
+}
+
+func init() {
+	addToChromeCmd.Flags().StringVarP(&outputFlag, "output", "o", "", "write export to this file (- for stdout)")
+	rootCmd.AddCommand(addToChromeCmd)
+}
+
+func runAddToChrome(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	path, err := config.Find(cwd)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+
+	projectDir := filepath.Dir(path)
+	current := export.Build(resolve.ForBranch(cfg, projectDir, false), projectDir)
+
+	if outputFlag == "-" {
+		data, err := export.Marshal(current)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	target := outputFlag
+	if target == "" {
+		target, err = defaultExportPath(projectDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	previous, err := export.Read(target)
+	if err != nil {
+		return fmt.Errorf("reading previous export: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	if err := export.Write(current, target); err != nil {
+		return err
+	}
+
+	printChanges(export.Diff(previous, current))
+	fmt.Fprintf(os.Stderr, "exported %d links to %s\n", len(current.Links), target)
+	return nil
+}
+
+// defaultExportPath returns a stable per-project export location. The
+// directory name keeps it readable; the hash keeps same-named projects apart.
+func defaultExportPath(projectDir string) (string, error) {
+	dir, err := userconfig.Dir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(projectDir))
+	name := fmt.Sprintf("%s-%s.json", filepath.Base(projectDir), hex.EncodeToString(sum[:4]))
+	return filepath.Join(dir, "chrome", name), nil
+}
+
+func printChanges(c export.Changes) {
+	if c.Empty() {
+		fmt.Fprintln(os.Stderr, "no changes since last export")
+		return
+	}
+	for _, name := range c.Added {
+		fmt.Fprintf(os.Stderr, "  + %s\n", name)
+	}
+	for _, name := range c.Changed {
+		fmt.Fprintf(os.Stderr, "  ~ %s\n", name)
+	}
+	for _, name := range c.Removed {
+		fmt.Fprintf(os.Stderr, "  - %s\n", name)
+	}
+}
@@ -3,17 +3,21 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 
 	"github.com/apermo/apermo-surf/internal/config"
+	"github.com/apermo/apermo-surf/internal/resolve"
 	"github.com/spf13/cobra"
 )
 
 var (
-	flagEnv   bool
-	flagTools bool
-	flagDocs  bool
+	flagEnv      bool
+	flagTools    bool
+	flagDocs     bool
+	flagCategory []string
+	flagAll      bool
 )
 
 var linksCmd = &cobra.Command{
@@ -27,23 +31,20 @@ func init() {
 	linksCmd.Flags().BoolVar(&flagEnv, "env", false, "show environments only")
 	linksCmd.Flags().BoolVar(&flagTools, "tools", false, "show tools only")
 	linksCmd.Flags().BoolVar(&flagDocs, "docs", false, "show docs only")
+	linksCmd.Flags().StringSliceVar(&flagCategory, "category", nil, "show only the named categories (repeatable)")
+	linksCmd.Flags().BoolVar(&flagAll, "all", false, "include links whose branch conditions don't match")
 	rootCmd.AddCommand(linksCmd)
 }
 
 func runLinks(cmd *cobra.Command, args []string) error {
-	cwd, err := os.Getwd()
+	cfg, projectDir, err := loadConfig()
 	if err != nil {
 		return err
 	}
 
-	path, err := config.Find(cwd)
-	if err != nil {
-		return err
-	}
-
-	cfg, err := config.Load(path)
-	if err != nil {
-		return err
+	cfg = resolve.ForBranch(cfg, projectDir, flagAll)
+	for _, c := range cfg.Collisions() {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", c)
 	}
 
 	if cfg.Name != "" {
@@ -51,14 +52,20 @@ func runLinks(cmd *cobra.Command, args []string) error {
 	}
 
 	cats := cfg.Categories()
+	for _, name := range flagCategory {
+		if !hasCategory(cats, name) {
+			return fmt.Errorf("unknown category %q", name)
+		}
+	}
 	filtered := filterCategories(cats)
+	layered := len(cfg.Files) > 1 || hasGlobal(cats)
 
 	for i, cat := range filtered {
 		if i > 0 {
 			fmt.Println()
 		}
 		fmt.Printf("%s:\n", cat.Name)
-		printLinks(cat.Links)
+		printLinks(cat.Links, layered, projectDir, flagAll)
 	}
 
 	return nil
@@ -66,7 +73,7 @@ func runLinks(cmd *cobra.Command, args []string) error {
 
 func filterCategories(cats []config.Category) []config.Category {
 	// No flags → show all
-	if !flagEnv && !flagTools && !flagDocs {
+	if !flagEnv && !flagTools && !flagDocs && len(flagCategory) == 0 {
 		return cats
 	}
 
@@ -75,6 +82,9 @@ func filterCategories(cats []config.Category) []config.Category {
 		"tools":        flagTools,
 		"docs":         flagDocs,
 	}
+	for _, name := range flagCategory {
+		allowed[name] = true
+	}
 
 	var out []config.Category
 	for _, cat := range cats {
@@ -85,35 +95,109 @@ func filterCategories(cats []config.Category) []config.Category {
 	return out
 }
 
-func printLinks(links map[string]config.Link) {
+// printLinks prints links sorted by name, with sub-links indented below
+// their parent. With layered set, each link is tagged with the config
+// layer it came from, relative to projectDir; with all set, with its branch
+// condition.
+func printLinks(links map[string]config.Link, layered bool, projectDir string, all bool) {
+	width := nameWidth(links, 0)
+	for _, name := range sortedNames(links) {
+		link := links[name]
+		var layer string
+		if layered {
+			layer = layerLabel(link.Source, projectDir)
+		}
+		fmt.Printf("  %-*s  %s%s\n", width, name, link.FullURL(), tags(link, layer, all))
+		printSubLinks(link, 1, width, all)
+	}
+}
+
+// printSubLinks prints the sub-links of parent with their joined URLs.
+func printSubLinks(parent config.Link, depth, width int, all bool) {
+	indent := strings.Repeat("  ", depth)
+	for _, name := range sortedNames(parent.Links) {
+		sub := parent.Links[name]
+		sub.URL = config.JoinURL(parent.URL, sub.URL)
+		fmt.Printf("  %s%-*s  %s%s\n", indent, width-2*depth, name, sub.FullURL(), tags(sub, "", all))
+		printSubLinks(sub, depth+1, width, all)
+	}
+}
+
+// tags formats the annotations shown after a link's URL: "search" for
+// search links, the branch condition when all links are listed, and the
+// config layer, if given.
+func tags(link config.Link, layer string, all bool) string {
+	var parts []string
+	if link.IsSearch() {
+		parts = append(parts, "search")
+	}
+	if all && link.When != nil && link.When.Branch != "" {
+		parts = append(parts, "when branch "+link.When.Branch)
+	}
+	if layer != "" {
+		parts = append(parts, layer)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "  (" + strings.Join(parts, ", ") + ")"
+}
+
+// nameWidth returns the column width needed to align names at depth and
+// their nested sub-links.
+func nameWidth(links map[string]config.Link, depth int) int {
+	width := 0
+	for name, link := range links {
+		width = max(width, len(name)+2*depth, nameWidth(link.Links, depth+1))
+	}
+	return width
+}
+
+func sortedNames(links map[string]config.Link) []string {
 	names := make([]string, 0, len(links))
-	maxLen := 0
 	for name := range links {
 		names = append(names, name)
-		if len(name) > maxLen {
-			maxLen = len(name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func hasCategory(cats []config.Category, name string) bool {
+	for _, cat := range cats {
+		if cat.Name == name {
+			return true
 		}
-		for sub := range links[name].Links {
-			if len(sub)+2 > maxLen {
-				maxLen = len(sub) + 2
+	}
+	return false
+}
+
+// hasGlobal reports whether any link comes from the user's global config.
+func hasGlobal(cats []config.Category) bool {
+	for _, cat := range cats {
+		for _, link := range cat.Links {
+			if link.Source == "" {
+				return true
 			}
 		}
 	}
-	sort.Strings(names)
+	return false
+}
 
-	for _, name := range names {
-		link := links[name]
-		fmt.Printf("  %-*s  %s\n", maxLen, name, link.URL)
-		if len(link.Links) > 0 {
-			subNames := make([]string, 0, len(link.Links))
-			for sub := range link.Links {
-				subNames = append(subNames, sub)
-			}
-			sort.Strings(subNames)
-			for _, sub := range subNames {
-				subURL := strings.TrimRight(link.URL, "/") + link.Links[sub]
-				fmt.Printf("    %-*s  %s\n", maxLen-2, sub, subURL)
-			}
+// layerLabel names the config layer a link was defined in: "global" for
+// the user config, "dist" or "local" for the project's own files, or the
+// relative path of an inherited ancestor config.
+func layerLabel(source, projectDir string) string {
+	if source == "" {
+		return "global"
+	}
+	if filepath.Dir(source) != projectDir {
+		if rel, err := filepath.Rel(projectDir, source); err == nil {
+			return rel
 		}
+		return source
+	}
+	if filepath.Base(source) == config.FileNameDist {
+		return "dist"
 	}
+	return "local"
 }
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/apermo/apermo-surf/internal/nativehost"
+	"github.com/apermo/apermo-surf/internal/userconfig"
+	"github.com/spf13/cobra"
+)
+
+var extensionIDs []string
+
+var nativeHostCmd = &cobra.Command{
+	Use:   "native-host",
+	Short: "Run as a Chrome native messaging host",
+	Long: `Speak Chrome's native messaging protocol on stdin/stdout so the
+apermo-surf-chrome extension can read project configs and resolve links.
+
+This command is started by the browser; run "surf native-host install" once
+to register it.`,
+	// Chrome passes the caller origin (and --parent-window on Windows).
+	Args:               cobra.ArbitraryArgs,
+	FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+	SilenceUsage:       true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return nativehost.Serve(os.Stdin, os.Stdout)
+	},
+}
+
+var nativeHostInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Register the native messaging host with Chrome and Chromium",
+	Args:  cobra.NoArgs,
+	RunE:  runNativeHostInstall,
+}
+
+func init() {
+	nativeHostInstallCmd.Flags().StringSliceVar(&extensionIDs, "extension-id", nil, "allowed extension ID (repeatable)")
+	_ = nativeHostInstallCmd.MarkFlagRequired("extension-id")
+	nativeHostCmd.AddCommand(nativeHostInstallCmd)
+	rootCmd.AddCommand(nativeHostCmd)
+}
+
+func runNativeHostInstall(cmd *cobra.Command, args []string) error {
+	surfPath, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	surfPath, err = filepath.EvalSymlinks(surfPath)
+	if err != nil {
+		return err
+	}
+
+	dir, err := userconfig.Dir()
+	if err != nil {
+		return err
+	}
+	wrapper := filepath.Join(dir, "native-host.sh")
+
+	// userconfig.Dir is <config home>/surf; browsers live next to it.
+	manifestDirs := nativehost.ManifestDirs(filepath.Dir(dir))
+
+	written, err := nativehost.Install(surfPath, wrapper, extensionIDs, manifestDirs)
+	for _, path := range written {
+		fmt.Fprintf(os.Stderr, "wrote %s\n", path)
+	}
+	return err
+}
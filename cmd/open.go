@@ -3,11 +3,10 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/apermo/apermo-surf/internal/browser"
-	"github.com/apermo/apermo-surf/internal/config"
 	"github.com/apermo/apermo-surf/internal/fuzzy"
 	"github.com/apermo/apermo-surf/internal/picker"
 	"github.com/apermo/apermo-surf/internal/resolve"
@@ -16,9 +15,9 @@ import (
 )
 
 var openCmd = &cobra.Command{
-	Use:               "open [name] [ticket]",
+	Use:               "open [name] [env] [param...] [ticket|query...]",
 	Short:             "Open a project link by fuzzy name",
-	Args:              cobra.RangeArgs(0, 2),
+	Args:              cobra.ArbitraryArgs,
 	RunE:              runOpen,
 	ValidArgsFunction: completeOpen,
 }
@@ -33,25 +32,25 @@ func completeOpen(cmd *cobra.Command, args []string, toComplete string) ([]strin
 		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
 
-	cwd, err := os.Getwd()
+	cfg, configDir, err := loadConfig()
 	if err != nil {
 		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
+	cfg = resolve.ForBranch(cfg, configDir, false)
 
-	path, err := config.Find(cwd)
-	if err != nil {
-		return nil, cobra.ShellCompDirectiveNoFileComp
-	}
-
-	cfg, err := config.Load(path)
-	if err != nil {
-		return nil, cobra.ShellCompDirectiveNoFileComp
-	}
-
-	allLinks := cfg.AllLinks()
+	// Offer qualified names (tools/jira/board) once the user types a slash
+	qualified := strings.Contains(toComplete, "/")
 	var completions []string
-	for name, link := range allLinks {
-		completions = append(completions, fmt.Sprintf("%s\t%s", name, link.URL))
+	for _, e := range cfg.Entries() {
+		name := e.Name
+		if qualified {
+			name = e.Qualified
+		}
+		desc := e.Link.FullURL()
+		if e.Link.IsSearch() {
+			desc = "search: " + desc
+		}
+		completions = append(completions, fmt.Sprintf("%s\t%s", name, desc))
 	}
 	sort.Strings(completions)
 
@@ -59,20 +58,11 @@ func completeOpen(cmd *cobra.Command, args []string, toComplete string) ([]strin
 }
 
 func runOpen(cmd *cobra.Command, args []string) error {
-	cwd, err := os.Getwd()
-	if err != nil {
-		return err
-	}
-
-	path, err := config.Find(cwd)
-	if err != nil {
-		return err
-	}
-
-	cfg, err := config.Load(path)
+	cfg, configDir, err := loadConfig()
 	if err != nil {
 		return err
 	}
+	cfg = resolve.ForBranch(cfg, configDir, false)
 
 	allLinks := cfg.AllLinks()
 	names := make([]string, 0, len(allLinks))
@@ -82,7 +72,7 @@ func runOpen(cmd *cobra.Command, args []string) error {
 	sort.Strings(names)
 
 	var match string
-	compoundMatched := false
+	var rest []string
 
 	if len(args) == 0 {
 		// Interactive picker mode
@@ -95,47 +85,51 @@ func runOpen(cmd *cobra.Command, args []string) error {
 			return err
 		}
 		match = names[idx]
+	} else if link, ok := cfg.Lookup(args[0]); ok {
+		// Qualified name (e.g. monitoring/grafana, tools/jira/board)
+		match, rest = args[0], args[1:]
+		allLinks[match] = link
+	} else if short, qualified := cfg.Ambiguous(args); qualified != nil {
+		return fmt.Errorf("%q is ambiguous — use %s", short, strings.Join(qualified, " or "))
 	} else {
-		// Two args: try compound name first (e.g. "admin staging"),
-		// then fall back to name + ticket semantics
-		if len(args) == 2 {
-			compound := args[0] + " " + args[1]
-			match, _ = fuzzy.BestMatch(compound, names)
-			compoundMatched = match != ""
+		// MatchArgs tries the first two args as a compound name (e.g.
+		// "admin staging") before the first alone; the remaining args are
+		// the env, params, and ticket or query
+		var candidates []string
+		match, rest, candidates = fuzzy.MatchArgs(args, names)
+
+		if match == "" && candidates == nil {
+			return fmt.Errorf("no link matching %q — run surf links to see available links", args[0])
 		}
 
 		if match == "" {
-			pattern := args[0]
-			var candidates []string
-			match, candidates = fuzzy.BestMatch(pattern, names)
-
-			if match == "" && candidates == nil {
-				return fmt.Errorf("no link matching %q — run surf links to see available links", pattern)
-			}
-
-			if match == "" {
-				fmt.Fprintf(os.Stderr, "ambiguous match for %q:\n", pattern)
-				for _, c := range candidates {
-					fmt.Fprintf(os.Stderr, "  %s  %s\n", c, allLinks[c].URL)
-				}
-				return fmt.Errorf("be more specific or use the full name")
+			fmt.Fprintf(os.Stderr, "ambiguous match for %q:\n", args[0])
+			for _, c := range candidates {
+				fmt.Fprintf(os.Stderr, "  %s  %s\n", c, allLinks[c].URL)
 			}
+			return fmt.Errorf("be more specific or use the full name")
 		}
 	}
 
 	link := allLinks[match]
-	configDir := filepath.Dir(path)
 
-	var explicitArg string
-	if len(args) == 2 && !compoundMatched {
-		explicitArg = args[1]
+	opts, err := resolve.ArgOptions(cfg, link, configDir, rest)
+	if err != nil {
+		return err
+	}
+	if picker.IsTerminal() {
+		opts.Prompt = picker.Prompt
+	}
+	result, err := resolve.ResolveWith(link, opts)
+	if err != nil {
+		return err
 	}
-	result := resolve.Resolve(link, configDir, explicitArg)
 
 	for _, w := range result.Warnings {
 		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
 	}
 
 	fmt.Printf("opening %s → %s\n", match, result.URL)
-	return browser.OpenWith(result.URL, browserFlag, userconfig.Load())
+	user, _ := userconfig.Load()
+	return browser.OpenWith(result.URL, browserFlag, user)
 }
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/apermo/apermo-surf/internal/git"
+	"github.com/spf13/cobra"
+)
+
+var pinFromClipboard bool
+
+var pinCmd = &cobra.Command{
+	Use:   "pin <name> [url]",
+	Short: "Pin a link to the current branch",
+	Long: `Store a link for the current git branch only, e.g. the preview deploy or
+the issue being fixed. Pinned links are kept in the repository's git config,
+never in .surf-links.yml, and show up under "pins" in surf links and surf open
+while the branch is checked out. Pinning an existing name replaces its URL.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runPin,
+}
+
+func init() {
+	pinCmd.Flags().BoolVar(&pinFromClipboard, "from-clipboard", false, "read the URL from the clipboard")
+	rootCmd.AddCommand(pinCmd)
+}
+
+func runPin(cmd *cobra.Command, args []string) error {
+	url, err := urlArg(args[1:], pinFromClipboard)
+	if err != nil {
+		return err
+	}
+	dir, branch, err := currentBranch()
+	if err != nil {
+		return err
+	}
+	if err := git.Pin(dir, branch, args[0], url); err != nil {
+		return err
+	}
+	fmt.Printf("pinned %s → %s on %s\n", args[0], url, branch)
+	return nil
+}
+
+// currentBranch returns the working directory and the git branch checked
+// out there.
+func currentBranch() (string, string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", "", err
+	}
+	branch, _ := git.Branch(dir)
+	if branch == "" || branch == "HEAD" {
+		return "", "", fmt.Errorf("pins need a checked-out git branch")
+	}
+	return dir, branch, nil
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/apermo/apermo-surf/internal/config"
+	"github.com/apermo/apermo-surf/internal/git"
+	"github.com/spf13/cobra"
+)
+
+var pinsCmd = &cobra.Command{
+	Use:   "pins",
+	Short: "List links pinned to the current branch",
+	Args:  cobra.NoArgs,
+	RunE:  runPins,
+}
+
+func init() {
+	rootCmd.AddCommand(pinsCmd)
+}
+
+func runPins(cmd *cobra.Command, args []string) error {
+	dir, branch, err := currentBranch()
+	if err != nil {
+		return err
+	}
+	pins, err := git.Pins(dir, branch)
+	if err != nil {
+		return err
+	}
+	if len(pins) == 0 {
+		fmt.Printf("no pins on %s — add one with surf pin <name> <url>\n", branch)
+		return nil
+	}
+
+	links := make(map[string]config.Link, len(pins))
+	for name, url := range pins {
+		links[name] = config.Link{URL: url}
+	}
+	fmt.Printf("%s:\n", branch)
+	printLinks(links, false, dir, false)
+	return nil
+}
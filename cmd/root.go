@@ -3,7 +3,10 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
+	"github.com/apermo/apermo-surf/internal/config"
+	"github.com/apermo/apermo-surf/internal/userconfig"
 	"github.com/spf13/cobra"
 )
 
@@ -21,9 +24,58 @@ Enable tab completion for link names:
 }
 
 func init() {
+	cobra.OnInitialize(registerTypes)
 	rootCmd.PersistentFlags().StringVarP(&browserFlag, "browser", "b", "", "browser to open URLs with")
 }
 
+// registerTypes makes the project types from the user config available to
+// every project config. Broken entries of the user config and broken type
+// definitions are reported and skipped.
+func registerTypes() {
+	user, err := userconfig.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: user config: %v\n", err)
+	}
+	if err := user.RegisterTypes(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: project types: %v\n", err)
+	}
+}
+
+// loadConfig loads the project config for the current directory layered
+// over the user's global links, and returns it with the directory used as
+// git context. Outside a project only the global links are returned.
+func loadConfig() (*config.Config, string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, "", err
+	}
+
+	user, _ := userconfig.Load()
+	global := user.Links()
+	if err := global.ExpandPresets(); err != nil {
+		return nil, "", fmt.Errorf("user config: %w", err)
+	}
+
+	path, err := config.Find(cwd)
+	if err != nil {
+		if len(global.AllLinks()) == 0 {
+			return nil, "", err
+		}
+		return global, cwd, nil
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	merged := config.Overlay(global, cfg)
+	if err := merged.Validate(); err != nil {
+		return nil, "", err
+	}
+	return merged, filepath.Dir(path), nil
+}
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
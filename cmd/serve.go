@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/apermo/apermo-surf/internal/server"
+	"github.com/spf13/cobra"
+)
+
+var addrFlag string
+
+var serveCmd = &cobra.Command{
+	Use:   "serve [project-dir...]",
+	Short: "Run a local go-links style redirect server",
+	Long: `Serve project links over HTTP: /prod or /jira/123 redirect to the URL that
+surf open would open, and /api/projects lists all links as JSON.
+
+Without arguments the project in the current directory is served. With
+several project directories, prefix paths with the directory name
+(/myproject/prod); the first project also answers unprefixed paths.
+Configs are reloaded automatically when the YAML file changes.`,
+	RunE: runServe,
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&addrFlag, "addr", "localhost:4242", "address to listen on")
+	rootCmd.AddCommand(serveCmd)
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	dirs := args
+	if len(dirs) == 0 {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		dirs = []string{cwd}
+	}
+
+	logger := log.New(os.Stderr, "surf: ", log.LstdFlags)
+	srv, err := server.New(dirs, logger)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "serving links on http://%s\n", addrFlag)
+	return http.ListenAndServe(addrFlag, srv)
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/apermo/apermo-surf/internal/browser"
+	"github.com/apermo/apermo-surf/internal/config"
+	"github.com/apermo/apermo-surf/internal/fuzzy"
+	"github.com/apermo/apermo-surf/internal/resolve"
+	"github.com/apermo/apermo-surf/internal/reverse"
+	"github.com/apermo/apermo-surf/internal/userconfig"
+	"github.com/spf13/cobra"
+)
+
+var switchFromClipboard bool
+
+var switchCmd = &cobra.Command{
+	Use:   "switch <env> [url]",
+	Short: "Open a URL from one environment on another",
+	Long: `Open the same path, query, and fragment on another environment, e.g. take a
+production URL from a bug report and open it on local:
+
+  surf switch local https://example.com/cart?item=42
+  surf switch local --from-clipboard`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runSwitch,
+}
+
+func init() {
+	switchCmd.Flags().BoolVar(&switchFromClipboard, "from-clipboard", false, "read the URL from the clipboard")
+	rootCmd.AddCommand(switchCmd)
+}
+
+func runSwitch(cmd *cobra.Command, args []string) error {
+	cfg, configDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	cfg = resolve.ForBranch(cfg, configDir, false)
+	rawURL, err := urlArg(args[1:], switchFromClipboard)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(cfg.Environments))
+	var entries []config.Entry
+	for name, link := range cfg.Environments {
+		names = append(names, name)
+		entries = append(entries, config.Entry{Name: name, Short: name, Qualified: "environments/" + name, Link: link})
+	}
+	sort.Strings(names)
+
+	envs := candidates(cfg, configDir, entries)
+	from, ok := reverse.Find(envs, rawURL)
+	if !ok {
+		return fmt.Errorf("%s does not belong to any environment", rawURL)
+	}
+
+	target, _ := fuzzy.BestMatch(args[0], names)
+	if target == "" {
+		return fmt.Errorf("no environment matching %q", args[0])
+	}
+	var targetURL string
+	for _, c := range envs {
+		if c.Name == target {
+			targetURL = c.URL
+		}
+	}
+	if targetURL == "" {
+		return fmt.Errorf("environment %q has no URL that resolves here", target)
+	}
+
+	url := config.JoinURL(targetURL, from.Rest)
+	fmt.Printf("opening %s → %s (from %s)\n", target, url, from.Name)
+	user, _ := userconfig.Load()
+	return browser.OpenWith(url, browserFlag, user)
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/apermo/apermo-surf/internal/trust"
+	"github.com/spf13/cobra"
+)
+
+var revokeFlag bool
+
+var trustCmd = &cobra.Command{
+	Use:   "trust [file...]",
+	Short: "Allow config files to run var commands",
+	Long: `Mark config files as trusted, which lets their vars: entries run commands.
+
+Without arguments, every file the current project config is loaded from is
+trusted. Trust is pinned to the file contents: after any edit, run surf trust
+again. Use --revoke to remove trust.`,
+	RunE: runTrust,
+}
+
+func init() {
+	trustCmd.Flags().BoolVar(&revokeFlag, "revoke", false, "remove trust instead of granting it")
+	rootCmd.AddCommand(trustCmd)
+}
+
+func runTrust(cmd *cobra.Command, args []string) error {
+	files := args
+	if len(files) == 0 {
+		cfg, _, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		files = cfg.Files
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no config files to trust")
+	}
+
+	store, err := trust.Default()
+	if err != nil {
+		return err
+	}
+
+	if revokeFlag {
+		if err := store.Revoke(files...); err != nil {
+			return err
+		}
+		for _, f := range files {
+			fmt.Printf("revoked %s\n", f)
+		}
+		return nil
+	}
+
+	if err := store.Trust(files...); err != nil {
+		return err
+	}
+	for _, f := range files {
+		fmt.Printf("trusted %s\n", f)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/apermo/apermo-surf/internal/git"
+	"github.com/spf13/cobra"
+)
+
+var unpinAllFlag bool
+
+var unpinCmd = &cobra.Command{
+	Use:               "unpin <name...>",
+	Short:             "Remove links pinned to the current branch",
+	Long:              "Remove the named pins from the current git branch, or all of them with --all.",
+	RunE:              runUnpin,
+	ValidArgsFunction: completePins,
+}
+
+func init() {
+	unpinCmd.Flags().BoolVar(&unpinAllFlag, "all", false, "remove every pin on the current branch")
+	rootCmd.AddCommand(unpinCmd)
+}
+
+func runUnpin(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 && !unpinAllFlag {
+		return fmt.Errorf("name the pins to remove, or use --all")
+	}
+	if len(args) > 0 && unpinAllFlag {
+		return fmt.Errorf("--all takes no names")
+	}
+	dir, branch, err := currentBranch()
+	if err != nil {
+		return err
+	}
+	if err := git.Unpin(dir, branch, args...); err != nil {
+		return err
+	}
+	if unpinAllFlag {
+		fmt.Printf("removed all pins on %s\n", branch)
+		return nil
+	}
+	for _, name := range args {
+		fmt.Printf("unpinned %s on %s\n", name, branch)
+	}
+	return nil
+}
+
+func completePins(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	dir, branch, err := currentBranch()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	pins, _ := git.Pins(dir, branch)
+	var names []string
+	for name, url := range pins {
+		names = append(names, name+"\t"+url)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
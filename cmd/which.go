@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/apermo/apermo-surf/internal/clipboard"
+	"github.com/apermo/apermo-surf/internal/config"
+	"github.com/apermo/apermo-surf/internal/resolve"
+	"github.com/apermo/apermo-surf/internal/reverse"
+	"github.com/spf13/cobra"
+)
+
+var whichFromClipboard bool
+
+var whichCmd = &cobra.Command{
+	Use:   "which <url>",
+	Short: "Show which link a URL belongs to",
+	Long: `Find the link whose resolved URL is the longest prefix of the given URL,
+including links generated from the project type, and show the rest of the URL.`,
+	Args: cobra.RangeArgs(0, 1),
+	RunE: runWhich,
+}
+
+func init() {
+	whichCmd.Flags().BoolVar(&whichFromClipboard, "from-clipboard", false, "read the URL from the clipboard")
+	rootCmd.AddCommand(whichCmd)
+}
+
+func runWhich(cmd *cobra.Command, args []string) error {
+	cfg, configDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	cfg = resolve.ForBranch(cfg, configDir, false)
+	rawURL, err := urlArg(args, whichFromClipboard)
+	if err != nil {
+		return err
+	}
+
+	m, ok := reverse.Find(candidates(cfg, configDir, cfg.Entries()), rawURL)
+	if !ok {
+		return fmt.Errorf("no link matches %s", rawURL)
+	}
+
+	fmt.Printf("%s  %s  (%s)\n", m.Name, m.URL, m.Qualified)
+	if m.Rest != "" {
+		fmt.Printf("  + %s\n", m.Rest)
+	}
+	return nil
+}
+
+// urlArg returns the URL argument, or the clipboard content when
+// fromClipboard is set.
+func urlArg(args []string, fromClipboard bool) (string, error) {
+	if fromClipboard {
+		if len(args) > 0 {
+			return "", fmt.Errorf("pass either a URL or --from-clipboard, not both")
+		}
+		return clipboard.Read()
+	}
+	if len(args) == 0 {
+		return "", fmt.Errorf("a URL is required (or use --from-clipboard)")
+	}
+	return args[0], nil
+}
+
+// candidates resolves the given entries for reverse lookup, the way
+// surf open would without arguments. Links that fail to resolve are skipped.
+func candidates(cfg *config.Config, dir string, entries []config.Entry) []reverse.Candidate {
+	var out []reverse.Candidate
+	for _, e := range entries {
+		opts, err := resolve.ArgOptions(cfg, e.Link, dir, nil)
+		if err != nil {
+			continue
+		}
+		result, err := resolve.ResolveWith(e.Link, opts)
+		if err != nil {
+			continue
+		}
+		out = append(out, reverse.Candidate{
+			Name:      e.Name,
+			Qualified: e.Qualified,
+			Generated: e.Generated,
+			URL:       result.URL,
+		})
+	}
+	return out
+}
@@ -0,0 +1,30 @@
+package clipboard
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// readers lists clipboard commands per OS, tried in order.
+var readers = map[string][][]string{
+	"darwin":  {{"pbpaste"}},
+	"linux":   {{"wl-paste", "--no-newline"}, {"xclip", "-selection", "clipboard", "-o"}, {"xsel", "--clipboard", "--output"}},
+	"windows": {{"powershell", "-NoProfile", "-Command", "Get-Clipboard"}},
+}
+
+// Read returns the trimmed text content of the system clipboard.
+func Read() (string, error) {
+	for _, args := range readers[runtime.GOOS] {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		out, err := exec.Command(args[0], args[1:]...).Output()
+		if err != nil {
+			continue
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+	return "", fmt.Errorf("no clipboard tool found (install wl-clipboard, xclip, or xsel)")
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"sort"
+)
+
+// Condition restricts a link to matching git branches. Branch is a glob
+// as in path.Match, so "release/*" matches "release/1.2" but "*" does not
+// match "feature/x".
+type Condition struct {
+	Branch string `yaml:"branch,omitempty"`
+}
+
+// Matches reports whether the condition holds on branch. A nil or empty
+// condition always holds.
+func (c *Condition) Matches(branch string) bool {
+	if c == nil || c.Branch == "" {
+		return true
+	}
+	ok, _ := path.Match(c.Branch, branch)
+	return ok
+}
+
+// ForBranch returns a copy of the config as seen on branch: links whose
+// when: condition does not match are dropped, and matching overrides are
+// applied. Links generated from the project type follow the remaining
+// environments.
+func (c *Config) ForBranch(branch string) *Config {
+	out := *c
+	out.Environments = linksForBranch(c.Environments, branch)
+	out.Tools = linksForBranch(c.Tools, branch)
+	out.Docs = linksForBranch(c.Docs, branch)
+	out.Custom = nil
+	for _, cat := range c.Custom {
+		out.Custom = append(out.Custom, Category{Name: cat.Name, Links: linksForBranch(cat.Links, branch)})
+	}
+	return &out
+}
+
+// PinCategory is the category pinned links are listed under.
+const PinCategory = "pins"
+
+// WithPins returns a copy of the config with pins (name → URL) added to the
+// pins category. Source records where the pins are stored; a pin overrides
+// the URL of a link of the same name already in that category.
+func (c *Config) WithPins(pins map[string]string, source string) *Config {
+	if len(pins) == 0 {
+		return c
+	}
+	links := make(map[string]Link, len(pins))
+	for name, url := range pins {
+		links[name] = Link{URL: url, Source: source}
+	}
+	out := *c
+	out.Custom = mergeCategories(c.Custom, []Category{{Name: PinCategory, Links: links}})
+	return &out
+}
+
+func linksForBranch(links map[string]Link, branch string) map[string]Link {
+	if links == nil {
+		return nil
+	}
+	out := make(map[string]Link, len(links))
+	for name, link := range links {
+		if link.When.Matches(branch) {
+			out[name] = link.ForBranch(branch)
+		}
+	}
+	return out
+}
+
+// ForBranch applies the most specific override matching branch (the
+// longest glob) and filters sub-links by their own conditions.
+func (l Link) ForBranch(branch string) Link {
+	if glob := l.overrideFor(branch); glob != "" {
+		o := l.Overrides[glob]
+		if o.Source == "" {
+			o.Source = l.Source
+		}
+		l = mergeLink(l, o)
+	}
+	l.Links = linksForBranch(l.Links, branch)
+	return l
+}
+
+// overrideFor returns the longest override glob matching branch, or "".
+func (l Link) overrideFor(branch string) string {
+	var globs []string
+	for glob := range l.Overrides {
+		if ok, _ := path.Match(glob, branch); ok {
+			globs = append(globs, glob)
+		}
+	}
+	if len(globs) == 0 {
+		return ""
+	}
+	sort.Slice(globs, func(i, j int) bool {
+		if len(globs[i]) != len(globs[j]) {
+			return len(globs[i]) > len(globs[j])
+		}
+		return globs[i] < globs[j]
+	})
+	return globs[0]
+}
+
+// validateBranches checks the when: and overrides: globs, and validates
+// each override as applied to the link.
+func (l Link) validateBranches() error {
+	if l.When != nil && l.When.Branch != "" {
+		if _, err := path.Match(l.When.Branch, ""); err != nil {
+			return fmt.Errorf("when: invalid branch glob %q", l.When.Branch)
+		}
+	}
+	for glob, o := range l.Overrides {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("overrides: invalid branch glob %q", glob)
+		}
+		merged := mergeLink(l, o)
+		merged.Overrides = nil
+		if err := merged.validate(); err != nil {
+			return fmt.Errorf("overrides %q: %w", glob, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+const branchYAML = `
+environments:
+  prod: https://example.com
+  preview:
+    url: https://{branch|slug}.preview.example.com
+    when:
+      branch: feature/*
+tools:
+  jira:
+    url: https://jira.example.com/board/1
+    overrides:
+      hotfix/*:
+        url: https://jira.example.com/board/9
+      hotfix/urgent-*:
+        url: https://jira.example.com/board/99
+    links:
+      release:
+        url: /releases
+        when:
+          branch: release/*
+`
+
+func TestCondition_Matches(t *testing.T) {
+	tests := []struct {
+		cond   *Condition
+		branch string
+		want   bool
+	}{
+		{nil, "main", true},
+		{&Condition{}, "main", true},
+		{&Condition{Branch: "release/*"}, "release/1.2", true},
+		{&Condition{Branch: "release/*"}, "release", false},
+		{&Condition{Branch: "*"}, "feature/x", false},
+		{&Condition{Branch: "main"}, "", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.cond.Matches(tt.branch); got != tt.want {
+			t.Errorf("%+v.Matches(%q) = %v, want %v", tt.cond, tt.branch, got, tt.want)
+		}
+	}
+}
+
+func TestConfig_ForBranch(t *testing.T) {
+	cfg, err := parseYAML(t, branchYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	tests := []struct {
+		branch      string
+		wantPreview bool
+		wantJira    string
+		wantRelease bool
+	}{
+		{"main", false, "https://jira.example.com/board/1", false},
+		{"feature/login", true, "https://jira.example.com/board/1", false},
+		{"hotfix/typo", false, "https://jira.example.com/board/9", false},
+		{"hotfix/urgent-outage", false, "https://jira.example.com/board/99", false},
+		{"release/2.0", false, "https://jira.example.com/board/1", true},
+		{"", false, "https://jira.example.com/board/1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			got := cfg.ForBranch(tt.branch)
+			if _, ok := got.Environments["preview"]; ok != tt.wantPreview {
+				t.Errorf("preview present = %v, want %v", ok, tt.wantPreview)
+			}
+			if _, ok := got.Environments["prod"]; !ok {
+				t.Error("unconditional prod link missing")
+			}
+			jira := got.Tools["jira"]
+			if jira.URL != tt.wantJira {
+				t.Errorf("jira = %q, want %q", jira.URL, tt.wantJira)
+			}
+			if _, ok := jira.Links["release"]; ok != tt.wantRelease {
+				t.Errorf("release sub-link present = %v, want %v", ok, tt.wantRelease)
+			}
+		})
+	}
+
+	if _, ok := cfg.Environments["preview"]; !ok {
+		t.Error("ForBranch modified the original config")
+	}
+}
+
+func TestLink_ForBranch_KeepsFields(t *testing.T) {
+	link := Link{
+		URL:     "https://jira.example.com/browse/{ticket}",
+		Pattern: "[A-Z]+-[0-9]+",
+		Source:  "/p/.surf-links.yml",
+		Overrides: map[string]Link{
+			"hotfix/*": {URL: "https://jira.example.com/hotfix/{ticket}"},
+		},
+	}
+
+	got := link.ForBranch("hotfix/x")
+	if got.URL != "https://jira.example.com/hotfix/{ticket}" {
+		t.Errorf("URL = %q", got.URL)
+	}
+	if got.Pattern != link.Pattern || got.Source != link.Source {
+		t.Errorf("override dropped inherited fields: %+v", got)
+	}
+}
+
+func TestValidate_BranchGlobs(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{"bad when", "tools:\n  a:\n    url: https://a.example.com\n    when:\n      branch: \"release/[\"\n", "invalid branch glob"},
+		{"bad override glob", "tools:\n  a:\n    url: https://a.example.com\n    overrides:\n      \"[\":\n        url: https://b.example.com\n", "invalid branch glob"},
+		{"bad override url", "tools:\n  a:\n    url: https://a.example.com\n    overrides:\n      main:\n        url: https://b.example.com/{branch|nope}\n", `overrides "main"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseYAML(t, tt.yaml)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMerge_BranchOverrides(t *testing.T) {
+	base := &Config{Tools: map[string]Link{"jira": {
+		URL:       "https://jira.example.com",
+		When:      &Condition{Branch: "main"},
+		Overrides: map[string]Link{"hotfix/*": {URL: "https://jira.example.com/hotfix"}},
+	}}}
+	overlay := &Config{Tools: map[string]Link{"jira": {
+		Overrides: map[string]Link{"release/*": {URL: "https://jira.example.com/release"}},
+	}}}
+
+	got := Merge(base, overlay).Tools["jira"]
+	if got.When == nil || got.When.Branch != "main" {
+		t.Errorf("When = %+v, want inherited", got.When)
+	}
+	if len(got.Overrides) != 2 {
+		t.Errorf("Overrides = %v, want both globs", got.Overrides)
+	}
+}
+
+func TestConfig_WithPins(t *testing.T) {
+	cfg := &Config{Custom: []Category{{Name: PinCategory, Links: map[string]Link{
+		"figma": {URL: "https://figma.com/old"},
+	}}}}
+
+	got := cfg.WithPins(map[string]string{
+		"figma":   "https://figma.com/new",
+		"preview": "https://pr-12.example.com",
+	}, "/repo/.git/config")
+
+	all := got.AllLinks()
+	if all["figma"].URL != "https://figma.com/new" || all["preview"].URL != "https://pr-12.example.com" {
+		t.Errorf("links = %v", all)
+	}
+	if all["preview"].Source != "/repo/.git/config" {
+		t.Errorf("source = %q", all["preview"].Source)
+	}
+	if cfg.Custom[0].Links["figma"].URL != "https://figma.com/old" {
+		t.Error("WithPins modified the original config")
+	}
+	if cfg.WithPins(nil, "") != cfg {
+		t.Error("WithPins without pins should return the config unchanged")
+	}
+}
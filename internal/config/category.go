@@ -0,0 +1,166 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// knownKeys holds the top-level keys that map to Config fields. Any other
+// top-level mapping is read as a custom link category.
+var knownKeys = func() map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}()
+
+// nearKnownKey returns the known key that key is most likely a typo of, or
+// "" when it is not close to any. Short keys allow one edit, longer ones two.
+func nearKnownKey(key string) string {
+	lower := strings.ToLower(key)
+	for known := range knownKeys {
+		limit := 1
+		if len(known) > 5 {
+			limit = 2
+		}
+		if editDistance(lower, known) <= limit {
+			return known
+		}
+	}
+	return ""
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// UnmarshalYAML decodes the known fields and collects every other
+// top-level mapping (e.g. monitoring:, ci:) as a custom category,
+// keeping the order in which they appear in the file. A key that looks like
+// a misspelled known key (enviroments:, tool:) is an error.
+func (c *Config) UnmarshalYAML(value *yaml.Node) error {
+	type plain Config
+	if err := value.Decode((*plain)(c)); err != nil {
+		return err
+	}
+	if value.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		key, val := value.Content[i], value.Content[i+1]
+		if knownKeys[key.Value] || val.Kind != yaml.MappingNode {
+			continue
+		}
+		if known := nearKnownKey(key.Value); known != "" {
+			return fmt.Errorf("line %d: unknown key %q, did you mean %q? (rename it to use it as a category)", key.Line, key.Value, known)
+		}
+		var links map[string]Link
+		if err := val.Decode(&links); err != nil {
+			return fmt.Errorf("category %q: %w", key.Value, err)
+		}
+		c.Custom = append(c.Custom, Category{Name: key.Value, Links: links})
+	}
+	return nil
+}
+
+// MarshalYAML writes the known fields followed by the custom categories.
+func (c Config) MarshalYAML() (interface{}, error) {
+	type plain Config
+	var node yaml.Node
+	if err := node.Encode(plain(c)); err != nil {
+		return nil, err
+	}
+
+	for _, cat := range c.Custom {
+		if len(cat.Links) == 0 {
+			continue
+		}
+		var val yaml.Node
+		if err := val.Encode(cat.Links); err != nil {
+			return nil, err
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: cat.Name}
+		node.Content = append(node.Content, key, &val)
+	}
+	return &node, nil
+}
+
+// Categories returns the non-empty categories in display order:
+// the names listed under categories: first, then environments, tools,
+// docs, and custom categories in file order.
+func (c *Config) Categories() []Category {
+	all := []Category{
+		{Name: "environments", Links: c.Environments},
+		{Name: "tools", Links: c.Tools},
+		{Name: "docs", Links: c.Docs},
+	}
+	all = append(all, c.Custom...)
+
+	var cats []Category
+	placed := make(map[string]bool)
+	add := func(cat Category) {
+		if placed[cat.Name] || len(cat.Links) == 0 {
+			return
+		}
+		placed[cat.Name] = true
+		cats = append(cats, cat)
+	}
+
+	for _, name := range c.Order {
+		for _, cat := range all {
+			if cat.Name == name {
+				add(cat)
+			}
+		}
+	}
+	for _, cat := range all {
+		add(cat)
+	}
+	return cats
+}
+
+// mergeCategories merges custom categories by name. Categories only present
+// in the overlay are appended after the base categories.
+func mergeCategories(base, o []Category) []Category {
+	var out []Category
+	index := make(map[string]int)
+	for _, cat := range base {
+		index[cat.Name] = len(out)
+		out = append(out, Category{Name: cat.Name, Links: mergeLinks(cat.Links, nil)})
+	}
+	for _, cat := range o {
+		if i, ok := index[cat.Name]; ok {
+			out[i].Links = mergeLinks(out[i].Links, cat.Links)
+			continue
+		}
+		index[cat.Name] = len(out)
+		out = append(out, Category{Name: cat.Name, Links: mergeLinks(nil, cat.Links)})
+	}
+	return out
+}
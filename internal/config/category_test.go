@@ -0,0 +1,158 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfig_CustomCategories(t *testing.T) {
+	cfg, err := parseYAML(t, `
+name: Shop
+monitoring:
+  grafana: https://grafana.example.com
+  uptime: https://uptime.example.com
+environments:
+  prod: https://example.com
+ci:
+  actions: https://github.com/org/shop/actions
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cats := cfg.Categories()
+	var names []string
+	for _, c := range cats {
+		names = append(names, c.Name)
+	}
+	if got := strings.Join(names, ","); got != "environments,monitoring,ci" {
+		t.Errorf("categories = %s, want built-ins then custom in file order", got)
+	}
+
+	all := cfg.AllLinks()
+	if all["grafana"].URL != "https://grafana.example.com" {
+		t.Errorf("grafana URL = %q", all["grafana"].URL)
+	}
+	if cfg.Name != "Shop" {
+		t.Error("known keys must not become categories")
+	}
+}
+
+func TestConfig_MisspelledKey(t *testing.T) {
+	for _, key := range []string{"enviroments", "tool", "Docs", "categorys", "var"} {
+		_, err := parseYAML(t, key+":\n  grafana: https://grafana.example.com\n")
+		if err == nil || !strings.Contains(err.Error(), "did you mean") {
+			t.Errorf("%s: error = %v, want a did-you-mean error", key, err)
+		}
+	}
+	for _, key := range []string{"ci", "logs", "nav", "pins", "design"} {
+		if _, err := parseYAML(t, key+":\n  grafana: https://grafana.example.com\n"); err != nil {
+			t.Errorf("%s: %v, want a custom category", key, err)
+		}
+	}
+}
+
+func TestConfig_CategoryOrder(t *testing.T) {
+	cfg, err := parseYAML(t, `
+categories: [monitoring, environments]
+environments:
+  prod: https://example.com
+tools:
+  jira: https://jira.example.com
+monitoring:
+  grafana: https://grafana.example.com
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cats := cfg.Categories()
+	var names []string
+	for _, c := range cats {
+		names = append(names, c.Name)
+	}
+	if got := strings.Join(names, ","); got != "monitoring,environments,tools" {
+		t.Errorf("categories = %s", got)
+	}
+}
+
+func TestConfig_Lookup(t *testing.T) {
+	cfg, err := parseYAML(t, `
+environments:
+  prod: https://example.com
+monitoring:
+  grafana: https://grafana.example.com
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	link, ok := cfg.Lookup("monitoring/grafana")
+	if !ok || link.URL != "https://grafana.example.com" {
+		t.Errorf("Lookup(monitoring/grafana) = %+v, %v", link, ok)
+	}
+	if _, ok := cfg.Lookup("monitoring/missing"); ok {
+		t.Error("expected no match for unknown link")
+	}
+	if _, ok := cfg.Lookup("grafana"); ok {
+		t.Error("expected no match for unqualified name")
+	}
+}
+
+func TestConfig_CustomCategorySource(t *testing.T) {
+	cfg, err := parseYAML(t, "monitoring:\n  grafana: https://grafana.example.com\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(cfg.Custom[0].Links["grafana"].Source) != FileName {
+		t.Errorf("source = %q", cfg.Custom[0].Links["grafana"].Source)
+	}
+}
+
+func TestConfig_MarshalYAML_CustomCategories(t *testing.T) {
+	cfg := &Config{
+		Environments: map[string]Link{"prod": {URL: "https://example.com"}},
+		Custom: []Category{
+			{Name: "monitoring", Links: map[string]Link{"grafana": {URL: "https://grafana.example.com"}}},
+		},
+	}
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var loaded Config
+	if err := yaml.Unmarshal(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Custom) != 1 || loaded.Custom[0].Links["grafana"].URL != "https://grafana.example.com" {
+		t.Errorf("round trip lost custom category:\n%s", data)
+	}
+}
+
+func TestMerge_CustomCategories(t *testing.T) {
+	base := &Config{Custom: []Category{
+		{Name: "monitoring", Links: map[string]Link{
+			"grafana": {URL: "https://grafana.example.com"},
+			"uptime":  {URL: "https://uptime.example.com"},
+		}},
+	}}
+	overlay := &Config{Custom: []Category{
+		{Name: "monitoring", Links: map[string]Link{"uptime": {}}},
+		{Name: "design", Links: map[string]Link{"figma": {URL: "https://figma.com"}}},
+	}}
+
+	merged := Merge(base, overlay)
+	if len(merged.Custom) != 2 {
+		t.Fatalf("got %d custom categories, want 2", len(merged.Custom))
+	}
+	if _, ok := merged.Custom[0].Links["uptime"]; ok {
+		t.Error("uptime should be deleted by overlay")
+	}
+	if merged.Custom[1].Name != "design" {
+		t.Errorf("second category = %q, want design", merged.Custom[1].Name)
+	}
+}
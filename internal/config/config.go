@@ -2,17 +2,71 @@ package config
 
 import (
 	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 
+	"github.com/apermo/apermo-surf/internal/placeholder"
 	"gopkg.in/yaml.v3"
 )
 
 // Link represents a project URL, either as a simple string or with a pattern.
-// Links is an optional map of sub-link names to relative paths.
+// Links is an optional map of named sub-links. A sub-link URL may be a path
+// ("/board"), a query ("?view=list") or fragment ("#top") relative to the
+// parent URL, or an absolute URL. Sub-links inherit the parent's pattern
+// unless they set their own, and can nest further.
+// Vars are environment-scoped variables, available to other links as
+// {env.<name>} when resolved against this environment.
+// Params declares named placeholders that are filled from positional
+// arguments, in the order they appear in the URL.
+// Query holds query parameters appended to the URL; values may contain
+// placeholders and are URL-encoded when resolved.
+// OnMissing is the policy for placeholders that cannot be resolved (see
+// OnMissingStrip and friends); FallbackURL is opened under the fallback policy.
+// When limits the link to matching branches, and Overrides replaces fields
+// on branches matching a glob key (see ForBranch).
+// Preset generates the link for a known service from Site and Project;
+// every other field set on the link overrides the generated one.
+// Source is the config file the link was (last) defined in; global marks
+// links layered in from the user's global config by Overlay.
 type Link struct {
-	URL     string            `yaml:"url"`
-	Pattern string            `yaml:"pattern,omitempty"`
-	Links   map[string]string `yaml:"links,omitempty"`
+	Preset      string            `yaml:"preset,omitempty"`
+	Site        string            `yaml:"site,omitempty"`
+	Project     string            `yaml:"project,omitempty"`
+	URL         string            `yaml:"url"`
+	Pattern     string            `yaml:"pattern,omitempty"`
+	Params      map[string]Param  `yaml:"params,omitempty"`
+	Query       map[string]string `yaml:"query,omitempty"`
+	OnMissing   string            `yaml:"on_missing,omitempty"`
+	FallbackURL string            `yaml:"fallback_url,omitempty"`
+	When        *Condition        `yaml:"when,omitempty"`
+	Overrides   map[string]Link   `yaml:"overrides,omitempty"`
+	Vars        map[string]string `yaml:"vars,omitempty"`
+	Links       map[string]Link   `yaml:"links,omitempty"`
+	Source      string            `yaml:"-"`
+	global      bool
+}
+
+// Policies for placeholders that cannot be resolved.
+const (
+	// OnMissingStrip drops the host label, path segment, query parameter,
+	// or fragment holding the placeholder. It is the default.
+	OnMissingStrip = "strip"
+	// OnMissingFallback opens the link's fallback_url instead.
+	OnMissingFallback = "fallback"
+	// OnMissingPrompt asks for the value on a terminal, and strips otherwise.
+	OnMissingPrompt = "prompt"
+	// OnMissingError refuses to open the link.
+	OnMissingError = "error"
+)
+
+// Param describes a named link parameter. Pattern, when set, must match the
+// whole value; Prompt is the label shown when surf asks for a missing value.
+type Param struct {
+	Pattern string `yaml:"pattern,omitempty"`
+	Prompt  string `yaml:"prompt,omitempty"`
 }
 
 func (l *Link) UnmarshalYAML(value *yaml.Node) error {
@@ -26,84 +80,460 @@ func (l *Link) UnmarshalYAML(value *yaml.Node) error {
 	return value.Decode((*plain)(l))
 }
 
-// MarshalYAML writes a Link as a scalar string when there is no pattern
-// or sub-links, or as a mapping otherwise.
+// MarshalYAML writes a Link as a scalar string when it has nothing but a
+// URL, or as a mapping otherwise.
 func (l Link) MarshalYAML() (interface{}, error) {
-	if l.Pattern == "" && len(l.Links) == 0 {
+	if l.isURLOnly() {
 		return l.URL, nil
 	}
 	return struct {
-		URL     string            `yaml:"url"`
-		Pattern string            `yaml:"pattern,omitempty"`
-		Links   map[string]string `yaml:"links,omitempty"`
-	}{l.URL, l.Pattern, l.Links}, nil
+		Preset      string            `yaml:"preset,omitempty"`
+		Site        string            `yaml:"site,omitempty"`
+		Project     string            `yaml:"project,omitempty"`
+		URL         string            `yaml:"url,omitempty"`
+		Pattern     string            `yaml:"pattern,omitempty"`
+		Params      map[string]Param  `yaml:"params,omitempty"`
+		Query       map[string]string `yaml:"query,omitempty"`
+		OnMissing   string            `yaml:"on_missing,omitempty"`
+		FallbackURL string            `yaml:"fallback_url,omitempty"`
+		When        *Condition        `yaml:"when,omitempty"`
+		Overrides   map[string]Link   `yaml:"overrides,omitempty"`
+		Vars        map[string]string `yaml:"vars,omitempty"`
+		Links       map[string]Link   `yaml:"links,omitempty"`
+	}{l.Preset, l.Site, l.Project, l.URL, l.Pattern, l.Params, l.Query, l.OnMissing, l.FallbackURL, l.When, l.Overrides, l.Vars, l.Links}, nil
 }
 
-// Category groups links under a name (environments, tools, docs).
+// FullURL returns the URL with the query map appended in key order, as
+// written: placeholders are kept and values are not encoded. It is meant
+// for display and for finding placeholders; resolve encodes the values.
+func (l Link) FullURL() string {
+	return AppendQuery(l.URL, l.Query, func(s string) string { return s })
+}
+
+// AppendQuery appends query to rawURL in key order, before any fragment.
+// Keys are URL-encoded and values passed through encode. A key already in
+// the query of rawURL is replaced, so the query map wins; other parameters
+// keep their place and encoding.
+func AppendQuery(rawURL string, query map[string]string, encode func(string) string) string {
+	if len(query) == 0 {
+		return rawURL
+	}
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	base, fragment, hasFragment := strings.Cut(rawURL, "#")
+	base, existing, _ := strings.Cut(base, "?")
+	var pairs []string
+	for _, pair := range strings.Split(existing, "&") {
+		if pair == "" {
+			continue
+		}
+		key, _, _ := strings.Cut(pair, "=")
+		if k, err := url.QueryUnescape(key); err == nil {
+			key = k
+		}
+		if _, ok := query[key]; !ok {
+			pairs = append(pairs, pair)
+		}
+	}
+	for _, k := range keys {
+		pairs = append(pairs, url.QueryEscape(k)+"="+encode(query[k]))
+	}
+	out := base + "?" + strings.Join(pairs, "&")
+	if hasFragment {
+		out += "#" + fragment
+	}
+	return out
+}
+
+// ParamNames returns the declared params in the order their placeholders
+// first appear in the URL. Declared params missing from the URL are left out.
+func (l Link) ParamNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, p := range placeholder.Parse(l.FullURL()) {
+		if _, ok := l.Params[p.Name]; ok && !seen[p.Name] {
+			seen[p.Name] = true
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// QueryPlaceholder is the placeholder that makes a link a search link:
+// every argument after the link name is joined into it.
+const QueryPlaceholder = "query"
+
+// IsSearch reports whether the link takes free-text search arguments
+// through a {query} placeholder.
+func (l Link) IsSearch() bool {
+	for _, p := range placeholder.Parse(l.FullURL()) {
+		if p.Name == QueryPlaceholder {
+			return true
+		}
+	}
+	return false
+}
+
+// child expands sub-link s against its parent l: the URL is joined with
+// the parent URL, unset pattern, on_missing policy, fallback URL, or source
+// are inherited, and the parent's params are merged underneath the
+// sub-link's own.
+func (l Link) child(s Link) Link {
+	out := s
+	out.URL = JoinURL(l.URL, s.URL)
+	out.Params = mergeParams(l.Params, s.Params)
+	if out.Pattern == "" {
+		out.Pattern = l.Pattern
+	}
+	if out.OnMissing == "" {
+		out.OnMissing = l.OnMissing
+	}
+	if out.FallbackURL == "" {
+		out.FallbackURL = l.FallbackURL
+	}
+	if out.Source == "" {
+		out.Source = l.Source
+	}
+	return out
+}
+
+// schemePrefix matches the scheme of an absolute URL ("https:", "mailto:").
+var schemePrefix = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+
+// JoinURL resolves ref against base. An absolute ref (one starting with a
+// scheme) replaces base, a
+// query ("?a=b") is appended to the base query, a fragment ("#x") replaces
+// the base fragment, and anything else is appended as a path segment,
+// keeping the base query ahead of any query in ref and dropping the base
+// fragment. Placeholders are left untouched, which is why base is split by
+// hand: net/url rejects templates such as https://{env.name}.example.com.
+func JoinURL(base, ref string) string {
+	switch {
+	case ref == "":
+		return base
+	case schemePrefix.MatchString(ref):
+		return ref
+	case strings.HasPrefix(ref, "#"):
+		before, _, _ := strings.Cut(base, "#")
+		return before + ref
+	case strings.HasPrefix(ref, "?"):
+		before, _, _ := strings.Cut(base, "#")
+		if strings.Contains(before, "?") {
+			return before + "&" + ref[1:]
+		}
+		return before + ref
+	default:
+		before, _, _ := strings.Cut(base, "#")
+		before, query, _ := strings.Cut(before, "?")
+		joined := strings.TrimRight(before, "/") + "/" + strings.TrimLeft(ref, "/")
+		if query == "" {
+			return joined
+		}
+		path, fragment, hasFragment := strings.Cut(joined, "#")
+		path, refQuery, _ := strings.Cut(path, "?")
+		out := path + "?" + query
+		if refQuery != "" {
+			out += "&" + refQuery
+		}
+		if hasFragment {
+			out += "#" + fragment
+		}
+		return out
+	}
+}
+
+// Dir returns the directory of the config file the link came from,
+// or "" for links not loaded from a file.
+func (l Link) Dir() string {
+	if l.Source == "" {
+		return ""
+	}
+	return filepath.Dir(l.Source)
+}
+
+// Category groups links under a name (environments, tools, docs, or a
+// custom top-level key such as monitoring).
 type Category struct {
 	Name  string
 	Links map[string]Link
 }
 
 // Config is the top-level .surf-links.yml structure.
+// Inherit merges the nearest ancestor config underneath this one, and
+// Include lists fragment files merged underneath this file's own keys.
+// Vars are named values available to links as {vars.<name>}.
+// Custom holds user-defined categories and Order their display order.
+// Files lists the config files it was loaded from, base layers first, and
+// Digests maps each of them to the sha256 of the contents that were loaded,
+// so trust is checked against what was parsed rather than the file on disk.
 type Config struct {
-	Name         string          `yaml:"name,omitempty"`
-	Type         *ProjectType    `yaml:"type,omitempty"`
-	Environments map[string]Link `yaml:"environments,omitempty"`
-	Tools        map[string]Link `yaml:"tools,omitempty"`
-	Docs         map[string]Link `yaml:"docs,omitempty"`
+	Name         string            `yaml:"name,omitempty"`
+	Inherit      bool              `yaml:"inherit,omitempty"`
+	Include      []string          `yaml:"include,omitempty"`
+	Type         *ProjectType      `yaml:"type,omitempty"`
+	Vars         map[string]Var    `yaml:"vars,omitempty"`
+	Environments map[string]Link   `yaml:"environments,omitempty"`
+	Tools        map[string]Link   `yaml:"tools,omitempty"`
+	Docs         map[string]Link   `yaml:"docs,omitempty"`
+	Order        []string          `yaml:"categories,omitempty"`
+	Custom       []Category        `yaml:"-"`
+	Files        []string          `yaml:"-"`
+	Digests      map[string]string `yaml:"-"`
 }
 
-// Categories returns the non-empty categories in display order.
-func (c *Config) Categories() []Category {
-	var cats []Category
-	if len(c.Environments) > 0 {
-		cats = append(cats, Category{Name: "environments", Links: c.Environments})
+// GeneratedCategory is the category of links generated from the project type.
+const GeneratedCategory = "type"
+
+// Entry is a single link in the flat link namespace.
+//
+// Short is the name the link is known by on its own ("jira board"), and
+// Qualified its unique category path ("tools/jira/board"). Name is the key
+// it is listed under in AllLinks: Short when that is unambiguous, else
+// Qualified.
+type Entry struct {
+	Name      string
+	Short     string
+	Qualified string
+	Category  string
+	Generated bool
+	Link      Link
+}
+
+// Entries returns every link, including generated type links and expanded
+// sub-links, sorted by Name.
+//
+// A short name shared by several explicit links is not used for any of
+// them. Explicit links take precedence over generated ones, which only keep
+// their short name when no explicit link claims it.
+func (c *Config) Entries() []Entry {
+	var entries []Entry
+
+	if c.Type != nil {
+		for name, link := range c.Type.GenerateLinks(c.projectEnvironments()) {
+			entries = append(entries, Entry{
+				Short:     name,
+				Qualified: GeneratedCategory + "/" + strings.ReplaceAll(name, " ", "/"),
+				Category:  GeneratedCategory,
+				Generated: true,
+				Link:      link,
+			})
+		}
+	}
+
+	for _, cat := range c.Categories() {
+		for name, link := range cat.Links {
+			qualified := cat.Name + "/" + name
+			entries = append(entries, Entry{Short: name, Qualified: qualified, Category: cat.Name, Link: link})
+			entries = appendSubEntries(entries, cat.Name, name, qualified, link)
+		}
+	}
+
+	explicit := make(map[string]int)
+	generated := make(map[string]int)
+	for _, e := range entries {
+		if e.Generated {
+			generated[e.Short]++
+		} else {
+			explicit[e.Short]++
+		}
 	}
-	if len(c.Tools) > 0 {
-		cats = append(cats, Category{Name: "tools", Links: c.Tools})
+
+	for i, e := range entries {
+		unique := explicit[e.Short] == 1
+		if e.Generated {
+			unique = explicit[e.Short] == 0 && generated[e.Short] == 1
+		}
+		entries[i].Name = e.Qualified
+		if unique {
+			entries[i].Name = e.Short
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// projectEnvironments returns the environments not layered in from the
+// global config; only those generate type links.
+func (c *Config) projectEnvironments() map[string]Link {
+	envs := make(map[string]Link, len(c.Environments))
+	for name, link := range c.Environments {
+		if !link.global {
+			envs[name] = link
+		}
 	}
-	if len(c.Docs) > 0 {
-		cats = append(cats, Category{Name: "docs", Links: c.Docs})
+	return envs
+}
+
+// appendSubEntries adds the sub-links of parent, recursively, with compound
+// short names ("github actions deploy") and slash-separated qualified names.
+func appendSubEntries(entries []Entry, category, short, qualified string, parent Link) []Entry {
+	for name, sub := range parent.Links {
+		link := parent.child(sub)
+		subShort := short + " " + name
+		subQualified := qualified + "/" + name
+		entries = append(entries, Entry{Short: subShort, Qualified: subQualified, Category: category, Link: link})
+		entries = appendSubEntries(entries, category, subShort, subQualified, link)
 	}
-	return cats
+	return entries
 }
 
 // AllLinks returns a flat map of all link names to their Link values.
-// Generated type links are added first; explicit links override them.
-// Sub-links are expanded into compound names (e.g. "jira board").
+// Sub-links are expanded into compound names (e.g. "jira board") and
+// carry their resolved URL and inherited pattern. Links whose short name
+// is ambiguous are listed under their qualified name instead (see
+// Entries); explicit links win over generated ones.
 func (c *Config) AllLinks() map[string]Link {
 	all := make(map[string]Link)
+	for _, e := range c.Entries() {
+		all[e.Name] = e.Link
+	}
+	return all
+}
 
-	// Generated links first (so explicit links can override)
-	if c.Type != nil {
-		for k, v := range c.Type.GenerateLinks(c.Environments) {
-			all[k] = v
+// Lookup finds a link by its qualified name (e.g. "monitoring/grafana"
+// or "tools/jira/board").
+func (c *Config) Lookup(qualified string) (Link, bool) {
+	for _, e := range c.Entries() {
+		if e.Qualified == qualified {
+			return e.Link, true
 		}
 	}
+	return Link{}, false
+}
 
-	for _, links := range []map[string]Link{c.Environments, c.Tools, c.Docs} {
-		for k, v := range links {
-			all[k] = v
-			for sub, path := range v.Links {
-				subURL := strings.TrimRight(v.URL, "/") + path
-				all[k+" "+sub] = Link{URL: subURL}
-			}
+// Collisions describes every short name shared by more than one explicit
+// link, naming the qualified names that can be used instead. A generated
+// link that an explicit one overrides is not a collision.
+func (c *Config) Collisions() []string {
+	byShort := make(map[string][]string)
+	for _, e := range c.Entries() {
+		if !e.Generated {
+			byShort[e.Short] = append(byShort[e.Short], e.Qualified)
 		}
 	}
-	return all
+
+	var out []string
+	for short, qualified := range byShort {
+		if len(qualified) > 1 {
+			sort.Strings(qualified)
+			out = append(out, fmt.Sprintf("%q is defined %d times — use %s", short, len(qualified), strings.Join(qualified, " or ")))
+		}
+	}
+	sort.Strings(out)
+	return out
 }
 
-// Validate checks that the config has at least one link and all links have URLs.
+// Ambiguous reports whether the leading args name a short name shared by
+// several explicit links, as in "surf open prod" with both an environment
+// and a tool called prod. It returns that short name and the qualified
+// names to use instead, or "" and nil.
+func (c *Config) Ambiguous(args []string) (string, []string) {
+	byShort := make(map[string][]string)
+	for _, e := range c.Entries() {
+		if !e.Generated {
+			byShort[e.Short] = append(byShort[e.Short], e.Qualified)
+		}
+	}
+	for i := len(args); i > 0; i-- {
+		short := strings.Join(args[:i], " ")
+		if qualified := byShort[short]; len(qualified) > 1 {
+			sort.Strings(qualified)
+			return short, qualified
+		}
+	}
+	return "", nil
+}
+
+// Validate checks that the config has at least one link, all links have
+// URLs with known placeholder filters, defined vars, valid param patterns,
+// and a known on_missing policy, and no two links share a qualified name.
+// Shared short names are allowed: such links are only reachable by their
+// qualified names (see Collisions and Ambiguous).
 func (c *Config) Validate() error {
-	all := c.AllLinks()
-	if len(all) == 0 {
+	entries := c.Entries()
+	if len(entries) == 0 {
 		return fmt.Errorf("config has no links defined")
 	}
-	for name, link := range all {
-		if link.URL == "" {
-			return fmt.Errorf("link %q has no url", name)
+
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		if e.Link.URL == "" {
+			if e.Link.Source != "" {
+				return fmt.Errorf("%s: link %q has no url", e.Link.Source, e.Short)
+			}
+			return fmt.Errorf("link %q has no url", e.Short)
+		}
+		if err := e.Link.validate(); err != nil {
+			if e.Link.Source != "" {
+				return fmt.Errorf("%s: link %q: %w", e.Link.Source, e.Short, err)
+			}
+			return fmt.Errorf("link %q: %w", e.Short, err)
+		}
+		if seen[e.Qualified] {
+			return fmt.Errorf("link %q is defined more than once", e.Qualified)
+		}
+		seen[e.Qualified] = true
+	}
+	return c.validateVars(entries)
+}
+
+// validate checks a single link's placeholders, params, branch globs, and
+// on_missing policy.
+func (l Link) validate() error {
+	if err := placeholder.Validate(l.FullURL()); err != nil {
+		return err
+	}
+	if err := placeholder.Validate(l.FallbackURL); err != nil {
+		return err
+	}
+	if err := l.validateParams(); err != nil {
+		return err
+	}
+	if err := l.validateBranches(); err != nil {
+		return err
+	}
+	return l.validateOnMissing()
+}
+
+// validateOnMissing checks the on_missing policy and that the fallback
+// policy comes with a fallback_url.
+func (l Link) validateOnMissing() error {
+	switch l.OnMissing {
+	case "", OnMissingStrip, OnMissingPrompt, OnMissingError:
+		return nil
+	case OnMissingFallback:
+		if l.FallbackURL == "" {
+			return fmt.Errorf("on_missing: fallback requires a fallback_url")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown on_missing policy %q (want strip, fallback, prompt, or error)", l.OnMissing)
+}
+
+// validateParams checks that every param pattern compiles.
+func (l Link) validateParams() error {
+	for name, p := range l.Params {
+		if p.Pattern == "" {
+			continue
+		}
+		if _, err := regexp.Compile(p.Pattern); err != nil {
+			return fmt.Errorf("param %q: invalid pattern: %w", name, err)
 		}
 	}
 	return nil
 }
+
+// Match reports whether value satisfies the param's pattern. The pattern
+// must match the whole value; a param without a pattern accepts anything.
+func (p Param) Match(value string) bool {
+	if p.Pattern == "" {
+		return true
+	}
+	re, err := regexp.Compile("^(?:" + p.Pattern + ")$")
+	return err == nil && re.MatchString(value)
+}
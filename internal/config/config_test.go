@@ -1,8 +1,11 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -42,6 +45,22 @@ tools:
 	}
 }
 
+func TestLink_UnmarshalYAML_Vars(t *testing.T) {
+	cfg, err := parseYAML(t, `
+environments:
+  prod:
+    url: https://example.com
+    vars:
+      sentry_env: production
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Environments["prod"].Vars["sentry_env"]; got != "production" {
+		t.Errorf("sentry_env = %q", got)
+	}
+}
+
 func TestConfig_Name(t *testing.T) {
 	cfg, err := parseYAML(t, `
 name: My Project
@@ -125,11 +144,11 @@ tools:
 	if len(link.Links) != 2 {
 		t.Fatalf("got %d sub-links, want 2", len(link.Links))
 	}
-	if link.Links["board"] != "/board" {
-		t.Errorf("board = %q", link.Links["board"])
+	if link.Links["board"].URL != "/board" {
+		t.Errorf("board = %q", link.Links["board"].URL)
 	}
-	if link.Links["backlog"] != "/backlog" {
-		t.Errorf("backlog = %q", link.Links["backlog"])
+	if link.Links["backlog"].URL != "/backlog" {
+		t.Errorf("backlog = %q", link.Links["backlog"].URL)
 	}
 }
 
@@ -163,7 +182,7 @@ tools:
 func TestLink_MarshalYAML_WithSubLinks(t *testing.T) {
 	l := Link{
 		URL:   "https://example.com",
-		Links: map[string]string{"board": "/board"},
+		Links: map[string]Link{"board": {URL: "/board"}},
 	}
 	val, err := l.MarshalYAML()
 	if err != nil {
@@ -192,6 +211,18 @@ func TestConfig_Validate_MissingURL(t *testing.T) {
 	}
 }
 
+func TestConfig_Validate_UnknownFilter(t *testing.T) {
+	cfg := &Config{
+		Tools: map[string]Link{
+			"preview": {URL: "https://{branch|kebab}.preview.example.com"},
+		},
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), `"kebab"`) {
+		t.Errorf("expected unknown filter error, got %v", err)
+	}
+}
+
 func TestFind_WalksUp(t *testing.T) {
 	dir := t.TempDir()
 	nested := filepath.Join(dir, "a", "b", "c")
@@ -375,3 +406,377 @@ func parseYAML(t *testing.T, input string) (*Config, error) {
 	}
 	return Load(path)
 }
+
+func TestLoad_Digests(t *testing.T) {
+	data := "tools:\n  jira: https://jira.example.com\n"
+	cfg, err := parseYAML(t, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(data))
+	if got := cfg.Digests[cfg.Files[0]]; got != hex.EncodeToString(sum[:]) {
+		t.Errorf("digest = %q, want the sha256 of the loaded contents", got)
+	}
+}
+
+func TestConfig_Entries_QualifiedNames(t *testing.T) {
+	cfg, err := parseYAML(t, `
+environments:
+  prod: https://example.com
+tools:
+  jira:
+    url: https://jira.example.com
+    links:
+      board: /board
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	qualified := make(map[string]string)
+	for _, e := range cfg.Entries() {
+		qualified[e.Name] = e.Qualified
+	}
+	if qualified["prod"] != "environments/prod" {
+		t.Errorf("prod qualified = %q", qualified["prod"])
+	}
+	if qualified["jira board"] != "tools/jira/board" {
+		t.Errorf("jira board qualified = %q", qualified["jira board"])
+	}
+
+	link, ok := cfg.Lookup("tools/jira/board")
+	if !ok || link.URL != "https://jira.example.com/board" {
+		t.Errorf("Lookup(tools/jira/board) = %+v, %v", link, ok)
+	}
+}
+
+func TestConfig_AllLinks_CollisionKeepsBoth(t *testing.T) {
+	cfg := &Config{
+		Environments: map[string]Link{"github": {URL: "https://github.example.com"}},
+		Tools: map[string]Link{
+			"github": {URL: "https://github.com/org/repo"},
+			"jira":   {URL: "https://jira.example.com"},
+		},
+	}
+
+	all := cfg.AllLinks()
+	if _, ok := all["github"]; ok {
+		t.Error("ambiguous short name should not be listed")
+	}
+	if all["environments/github"].URL != "https://github.example.com" {
+		t.Errorf("environments/github = %q", all["environments/github"].URL)
+	}
+	if all["tools/github"].URL != "https://github.com/org/repo" {
+		t.Errorf("tools/github = %q", all["tools/github"].URL)
+	}
+	if all["jira"].URL == "" {
+		t.Error("unique short name should still work")
+	}
+
+	collisions := cfg.Collisions()
+	if len(collisions) != 1 || !strings.Contains(collisions[0], "tools/github") {
+		t.Errorf("collisions = %v", collisions)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() = %v, shared short names should still load", err)
+	}
+	short, qualified := cfg.Ambiguous([]string{"github", "123"})
+	if short != "github" || strings.Join(qualified, ",") != "environments/github,tools/github" {
+		t.Errorf("Ambiguous = %q, %v", short, qualified)
+	}
+	if _, qualified := cfg.Ambiguous([]string{"jira"}); qualified != nil {
+		t.Errorf("unique name reported as ambiguous: %v", qualified)
+	}
+}
+
+func TestConfig_AllLinks_ExplicitShadowsGenerated(t *testing.T) {
+	cfg := &Config{
+		Type: &ProjectType{Name: "wordpress", AdminPath: "/wp-admin"},
+		Environments: map[string]Link{
+			"staging": {URL: "https://staging.example.com"},
+		},
+		Tools: map[string]Link{
+			"admin staging": {URL: "https://custom-admin.example.com"},
+		},
+	}
+
+	all := cfg.AllLinks()
+	if all["admin staging"].URL != "https://custom-admin.example.com" {
+		t.Errorf("admin staging = %q, want explicit link", all["admin staging"].URL)
+	}
+	if all["type/admin/staging"].URL != "https://staging.example.com/wp-admin" {
+		t.Errorf("generated link should stay reachable, got %q", all["type/admin/staging"].URL)
+	}
+	if c := cfg.Collisions(); len(c) != 0 {
+		t.Errorf("explicit link overriding a generated one is not a collision: %v", c)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+}
+
+func TestConfig_Validate_QualifiedCollision(t *testing.T) {
+	cfg := &Config{
+		Tools: map[string]Link{
+			"jira":       {URL: "https://jira.example.com", Links: map[string]Link{"board": {URL: "/board"}}},
+			"jira/board": {URL: "https://board.example.com"},
+		},
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for duplicate qualified name")
+	}
+}
+
+func TestJoinURL(t *testing.T) {
+	tests := []struct {
+		name string
+		base string
+		ref  string
+		want string
+	}{
+		{"path", "https://example.com", "/board", "https://example.com/board"},
+		{"path trailing slash", "https://example.com/", "/board", "https://example.com/board"},
+		{"path without slash", "https://example.com/a", "board", "https://example.com/a/board"},
+		{"path keeps query", "https://example.com/a?x=1#top", "/b", "https://example.com/a/b?x=1"},
+		{"deep link keeps query", "https://x.example.com/app?lang=en", "/wp-admin", "https://x.example.com/app/wp-admin?lang=en"},
+		{"path keeps query before own", "https://x.example.com/app?lang=en", "/wp-admin/plugins.php?plugin_status=active#list", "https://x.example.com/app/wp-admin/plugins.php?lang=en&plugin_status=active#list"},
+		{"query", "https://example.com/search", "?q=1", "https://example.com/search?q=1"},
+		{"query appends", "https://example.com/search?a=1#top", "?q=1", "https://example.com/search?a=1&q=1"},
+		{"fragment", "https://example.com/page#old", "#new", "https://example.com/page#new"},
+		{"absolute", "https://example.com", "https://other.example.com/x", "https://other.example.com/x"},
+		{"absolute without slashes", "https://example.com", "mailto:team@example.com", "mailto:team@example.com"},
+		{"path with url in query", "https://example.com", "/login?next=https://app.example.com", "https://example.com/login?next=https://app.example.com"},
+		{"query with url", "https://example.com/cb", "?u=https://x.example.com", "https://example.com/cb?u=https://x.example.com"},
+		{"empty", "https://example.com", "", "https://example.com"},
+		{"placeholder", "https://jira.example.com/browse/{ticket}", "/board", "https://jira.example.com/browse/{ticket}/board"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := JoinURL(tt.base, tt.ref); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_AllLinks_NestedSubLinks(t *testing.T) {
+	cfg, err := parseYAML(t, `
+tools:
+  jira:
+    url: https://jira.example.com/browse/{ticket}
+    pattern: "PROJ-\\d+"
+    links:
+      board: https://jira.example.com/boards/1
+      search:
+        url: https://jira.example.com/issues/?jql=text~{ticket}
+        pattern: "\\d+"
+  github:
+    url: https://github.com/org/repo
+    links:
+      actions:
+        url: /actions
+        links:
+          deploy: /workflows/deploy.yml
+      open-prs: ?q=is:open
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	all := cfg.AllLinks()
+	if all["jira board"].URL != "https://jira.example.com/boards/1" {
+		t.Errorf("jira board URL = %q, want absolute URL", all["jira board"].URL)
+	}
+	if all["jira board"].Pattern != `PROJ-\d+` {
+		t.Errorf("jira board pattern = %q, want inherited", all["jira board"].Pattern)
+	}
+	if all["jira search"].Pattern != `\d+` {
+		t.Errorf("jira search pattern = %q, want override", all["jira search"].Pattern)
+	}
+	if all["github actions deploy"].URL != "https://github.com/org/repo/actions/workflows/deploy.yml" {
+		t.Errorf("github actions deploy URL = %q", all["github actions deploy"].URL)
+	}
+	if all["github open-prs"].URL != "https://github.com/org/repo?q=is:open" {
+		t.Errorf("github open-prs URL = %q", all["github open-prs"].URL)
+	}
+	if _, ok := cfg.Lookup("tools/github/actions/deploy"); !ok {
+		t.Error("nested sub-link should have a qualified name")
+	}
+	if filepath.Base(all["github actions deploy"].Source) != FileName {
+		t.Errorf("nested sub-link source = %q", all["github actions deploy"].Source)
+	}
+}
+
+func TestWrite_RoundTrip_SubLinks(t *testing.T) {
+	original := &Config{
+		Tools: map[string]Link{
+			"github": {
+				URL: "https://github.com/org/repo",
+				Links: map[string]Link{
+					"prs": {URL: "/pulls"},
+					"actions": {
+						URL:     "/actions",
+						Pattern: `\d+`,
+						Links:   map[string]Link{"deploy": {URL: "/workflows/deploy.yml"}},
+					},
+				},
+			},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := Write(original, path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "prs: /pulls") {
+		t.Errorf("simple sub-link should be written as a scalar:\n%s", data)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := loaded.Tools["github"].Links["actions"]
+	if actions.Pattern != `\d+` || actions.Links["deploy"].URL != "/workflows/deploy.yml" {
+		t.Errorf("actions = %+v", actions)
+	}
+}
+
+func TestLink_Params(t *testing.T) {
+	cfg, err := parseYAML(t, `
+tools:
+  orders:
+    url: https://admin.example.com/{shop}/orders/{order_id}
+    params:
+      order_id:
+        pattern: "\\d+"
+        prompt: Order ID
+      shop: {}
+    links:
+      invoice: /invoice/{invoice_id}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	orders := cfg.Tools["orders"]
+	if got := strings.Join(orders.ParamNames(), ","); got != "shop,order_id" {
+		t.Errorf("param names = %s, want URL order", got)
+	}
+	if !orders.Params["order_id"].Match("4711") || orders.Params["order_id"].Match("47a") {
+		t.Error("order_id pattern must match whole value")
+	}
+
+	invoice := cfg.AllLinks()["orders invoice"]
+	if got := strings.Join(invoice.ParamNames(), ","); got != "shop,order_id" {
+		t.Errorf("sub-link param names = %s, want inherited params", got)
+	}
+}
+
+func TestConfig_Validate_InvalidParamPattern(t *testing.T) {
+	cfg := &Config{Tools: map[string]Link{
+		"orders": {URL: "https://example.com/{id}", Params: map[string]Param{"id": {Pattern: "("}}},
+	}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for invalid param pattern")
+	}
+}
+
+func TestLink_IsSearch(t *testing.T) {
+	tests := map[string]bool{
+		"https://wiki.example.com/search?text={query}":       true,
+		"https://wiki.example.com/search?text={query|lower}": true,
+		"https://wiki.example.com/{ticket}":                  false,
+		"https://wiki.example.com/search?query=x":            false,
+	}
+	for url, want := range tests {
+		if got := (Link{URL: url}).IsSearch(); got != want {
+			t.Errorf("IsSearch(%q) = %v, want %v", url, got, want)
+		}
+	}
+}
+
+func TestConfig_Validate_OnMissing(t *testing.T) {
+	tests := []struct {
+		name    string
+		link    Link
+		wantErr bool
+	}{
+		{"strip", Link{URL: "https://example.com/{ticket}", OnMissing: OnMissingStrip}, false},
+		{"fallback with url", Link{URL: "https://example.com/{ticket}", OnMissing: OnMissingFallback, FallbackURL: "https://example.com"}, false},
+		{"fallback without url", Link{URL: "https://example.com/{ticket}", OnMissing: OnMissingFallback}, true},
+		{"unknown policy", Link{URL: "https://example.com/{ticket}", OnMissing: "ignore"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Tools: map[string]Link{"jira": tt.link}}
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLink_Query(t *testing.T) {
+	cfg, err := parseYAML(t, `
+tools:
+  sentry:
+    url: https://sentry.io/issues/#top
+    query:
+      environment: "{env.sentry_env}"
+      query: "release:{commit}"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sentry := cfg.Tools["sentry"]
+	want := "https://sentry.io/issues/?environment={env.sentry_env}&query=release:{commit}#top"
+	if got := sentry.FullURL(); got != want {
+		t.Errorf("FullURL = %q, want %q", got, want)
+	}
+}
+
+func TestAppendQuery(t *testing.T) {
+	query := map[string]string{"environment": "prod", "statsPeriod": "24h"}
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"no query", "https://sentry.io/issues", "https://sentry.io/issues?environment=prod&statsPeriod=24h"},
+		{"other keys kept", "https://sentry.io/issues?project=1#top", "https://sentry.io/issues?project=1&environment=prod&statsPeriod=24h#top"},
+		{"query map wins", "https://sentry.io/issues?environment=staging&project=1", "https://sentry.io/issues?project=1&environment=prod&statsPeriod=24h"},
+		{"encoded key", "https://sentry.io/issues?stats%50eriod=1h", "https://sentry.io/issues?environment=prod&statsPeriod=24h"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AppendQuery(tt.url, query, func(s string) string { return s })
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerge_Query(t *testing.T) {
+	base := &Config{Tools: map[string]Link{
+		"sentry": {URL: "https://sentry.io", Query: map[string]string{"environment": "prod", "statsPeriod": "24h"}},
+	}}
+	overlay := &Config{Tools: map[string]Link{
+		"sentry": {Query: map[string]string{"statsPeriod": "", "project": "1"}},
+	}}
+
+	got := Merge(base, overlay).Tools["sentry"].Query
+	if len(got) != 2 || got["environment"] != "prod" || got["project"] != "1" {
+		t.Errorf("query = %v", got)
+	}
+}
@@ -12,6 +12,7 @@ const FileNameDist = ".surf-links.yml.dist"
 // Find walks up from startDir looking for .surf-links.yml.
 // At each level, .surf-links.yml is checked first; if absent,
 // .surf-links.yml.dist is used as fallback. Closest ancestor wins.
+// When both exist, the local file is returned and Load layers it over the dist.
 func Find(startDir string) (string, error) {
 	dir, err := filepath.Abs(startDir)
 	if err != nil {
@@ -38,3 +39,14 @@ func Find(startDir string) (string, error) {
 
 	return "", fmt.Errorf("no %s found — run surf init to create one", FileName)
 }
+
+// FindParent continues the search of Find above the directory containing
+// path, returning the nearest ancestor config.
+func FindParent(path string) (string, error) {
+	dir := filepath.Dir(path)
+	parent := filepath.Dir(dir)
+	if parent == dir {
+		return "", fmt.Errorf("no %s found above %s", FileName, dir)
+	}
+	return Find(parent)
+}
@@ -1,13 +1,98 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
-// Load reads, parses, and validates a .surf-links.yml file.
+// Load reads, parses, and validates a .surf-links.yml file, expanding tool
+// presets once all layers are merged.
+// When path is a .surf-links.yml with a .surf-links.yml.dist next to it,
+// the local file is merged as an overlay on top of the dist file.
+// When the result sets inherit: true, the nearest ancestor config is
+// loaded the same way and merged underneath, closer files winning.
 func Load(path string) (*Config, error) {
+	cfg, err := loadChain(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.ExpandPresets(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// loadChain loads path and, if it opts in via inherit, its ancestors.
+func loadChain(path string) (*Config, error) {
+	cfg, err := loadLayers(path)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Inherit {
+		return cfg, nil
+	}
+
+	parent, err := FindParent(path)
+	if err != nil {
+		// Nothing above to inherit from
+		return cfg, nil
+	}
+	base, err := loadChain(parent)
+	if err != nil {
+		return nil, err
+	}
+	return Merge(base, cfg), nil
+}
+
+// loadLayers loads a single directory's config: the file at path, overlaid
+// on the sibling .dist file when path is the local file.
+func loadLayers(path string) (*Config, error) {
+	cfg, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if filepath.Base(path) == FileName {
+		dist := filepath.Join(filepath.Dir(path), FileNameDist)
+		if _, err := os.Stat(dist); err == nil {
+			base, err := readFile(dist)
+			if err != nil {
+				return nil, err
+			}
+			cfg = Merge(base, cfg)
+		}
+	}
+	return cfg, nil
+}
+
+// readFile parses a single config file without validating it, stamping
+// every link with the file it came from. Files listed under include are
+// merged first, in order, so the file's own keys override them.
+func readFile(path string) (*Config, error) {
+	return readFileStack(path, nil)
+}
+
+// readFileStack is readFile with the chain of including files, used to
+// detect include cycles.
+func readFileStack(path string, stack []string) (*Config, error) {
+	for _, p := range stack {
+		if p == path {
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(append(stack, path), " → "))
+		}
+	}
+	stack = append(stack, path)
+
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
@@ -15,12 +100,70 @@ func Load(path string) (*Config, error) {
 
 	var cfg Config
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%s: %w", path, err)
 	}
 
-	if err := cfg.Validate(); err != nil {
-		return nil, err
+	stampSource(cfg.Environments, path)
+	stampSource(cfg.Tools, path)
+	stampSource(cfg.Docs, path)
+	for _, cat := range cfg.Custom {
+		stampSource(cat.Links, path)
 	}
+	for name, v := range cfg.Vars {
+		v.Source = path
+		cfg.Vars[name] = v
+	}
+	cfg.Files = []string{path}
+	sum := sha256.Sum256(data)
+	cfg.Digests = map[string]string{path: hex.EncodeToString(sum[:])}
+
+	if len(cfg.Include) == 0 {
+		return &cfg, nil
+	}
+
+	base := &Config{}
+	for _, inc := range cfg.Include {
+		incPath, err := includePath(path, inc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: include %q: %w", path, inc, err)
+		}
+		fragment, err := readFileStack(incPath, stack)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("%s: include %q: file not found", path, inc)
+			}
+			return nil, err
+		}
+		base = Merge(base, fragment)
+	}
+	cfg.Include = nil
+
+	return Merge(base, &cfg), nil
+}
+
+// stampSource records path as the source of links, their sub-links, and
+// their branch overrides.
+func stampSource(links map[string]Link, path string) {
+	for name, link := range links {
+		link.Source = path
+		stampSource(link.Links, path)
+		stampSource(link.Overrides, path)
+		links[name] = link
+	}
+}
 
-	return &cfg, nil
+// includePath resolves an include entry relative to the including file.
+// A leading ~/ refers to the user's home directory.
+func includePath(from, inc string) (string, error) {
+	if rest, ok := strings.CutPrefix(inc, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, rest), nil
+	}
+	if filepath.IsAbs(inc) {
+		return filepath.Clean(inc), nil
+	}
+	return filepath.Join(filepath.Dir(from), inc), nil
 }
@@ -0,0 +1,221 @@
+package config
+
+// Merge overlays o onto base and returns the combined config.
+// Neither input is modified. Scalars (name, type) are replaced when set in
+// the overlay; link maps and top-level vars are merged key by key, including
+// link vars and sub-links.
+//
+// An overlay entry without a URL, pattern, or sub-links (e.g. "sentry: ~"
+// or "backlog: \"\"") deletes the inherited link or sub-link.
+func Merge(base, o *Config) *Config {
+	out := &Config{
+		Name:         base.Name,
+		Inherit:      base.Inherit || o.Inherit,
+		Type:         base.Type,
+		Vars:         mergeConfigVars(base.Vars, o.Vars),
+		Environments: mergeLinks(base.Environments, o.Environments),
+		Tools:        mergeLinks(base.Tools, o.Tools),
+		Docs:         mergeLinks(base.Docs, o.Docs),
+		Order:        base.Order,
+		Custom:       mergeCategories(base.Custom, o.Custom),
+	}
+	if len(o.Order) > 0 {
+		out.Order = o.Order
+	}
+	if o.Name != "" {
+		out.Name = o.Name
+	}
+	if o.Type != nil {
+		out.Type = o.Type
+	}
+	out.Files = append(append([]string{}, base.Files...), o.Files...)
+	out.Digests = mergeVars(base.Digests, o.Digests)
+	return out
+}
+
+// Overlay layers a project config over the user's global links. Unlike
+// Merge, a project link replaces a global link of the same name as a whole,
+// without inheriting its pattern, fallback or sub-links. It also shadows a
+// global link with the same short name in any other category, so project
+// links win every name collision. Global environments don't generate links
+// from the project type.
+func Overlay(global, project *Config) *Config {
+	// Sub-link names start with their parent's name, so shadowing the
+	// top-level names covers them too.
+	used := make(map[string]bool)
+	for _, links := range []map[string]Link{project.Environments, project.Tools, project.Docs} {
+		for name, link := range links {
+			used[name] = used[name] || !link.isEmpty()
+		}
+	}
+	for _, cat := range project.Custom {
+		for name, link := range cat.Links {
+			used[name] = used[name] || !link.isEmpty()
+		}
+	}
+
+	out := Merge(global, project)
+	out.Environments = overlayLinks(global.Environments, project.Environments, used)
+	out.Tools = overlayLinks(global.Tools, project.Tools, used)
+	out.Docs = overlayLinks(global.Docs, project.Docs, used)
+	out.Custom = nil
+	index := make(map[string]int)
+	for _, cat := range global.Custom {
+		index[cat.Name] = len(out.Custom)
+		out.Custom = append(out.Custom, Category{Name: cat.Name, Links: overlayLinks(cat.Links, nil, used)})
+	}
+	for _, cat := range project.Custom {
+		if i, ok := index[cat.Name]; ok {
+			out.Custom[i].Links = overlayLinks(out.Custom[i].Links, cat.Links, nil)
+			continue
+		}
+		index[cat.Name] = len(out.Custom)
+		out.Custom = append(out.Custom, Category{Name: cat.Name, Links: overlayLinks(nil, cat.Links, nil)})
+	}
+	return out
+}
+
+// overlayLinks marks the global links and replaces those the project
+// redefines; an empty project link deletes the global one. Global links
+// named in shadowed are dropped.
+func overlayLinks(global, project map[string]Link, shadowed map[string]bool) map[string]Link {
+	out := make(map[string]Link, len(global)+len(project))
+	for k, v := range global {
+		if v.global {
+			// Already overlaid, as for custom categories.
+			out[k] = v
+			continue
+		}
+		if shadowed[k] {
+			continue
+		}
+		v.global = true
+		out[k] = v
+	}
+	for k, v := range project {
+		if v.isEmpty() {
+			delete(out, k)
+			continue
+		}
+		out[k] = v
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func mergeLinks(base, o map[string]Link) map[string]Link {
+	if len(base) == 0 && len(o) == 0 {
+		return nil
+	}
+
+	out := make(map[string]Link, len(base)+len(o))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range o {
+		if v.isEmpty() {
+			delete(out, k)
+			continue
+		}
+		if b, ok := out[k]; ok {
+			out[k] = mergeLink(b, v)
+		} else {
+			out[k] = v
+		}
+	}
+
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func mergeLink(base, o Link) Link {
+	out := base
+	if o.Preset != "" {
+		out.Preset = o.Preset
+	}
+	if o.Site != "" {
+		out.Site = o.Site
+	}
+	if o.Project != "" {
+		out.Project = o.Project
+	}
+	if o.URL != "" {
+		out.URL = o.URL
+	}
+	if o.Pattern != "" {
+		out.Pattern = o.Pattern
+	}
+	if o.OnMissing != "" {
+		out.OnMissing = o.OnMissing
+	}
+	if o.FallbackURL != "" {
+		out.FallbackURL = o.FallbackURL
+	}
+	if o.When != nil {
+		out.When = o.When
+	}
+	out.Overrides = mergeLinks(base.Overrides, o.Overrides)
+	out.Source = o.Source
+	out.Params = mergeParams(base.Params, o.Params)
+	out.Query = mergeVars(base.Query, o.Query)
+	out.Vars = mergeVars(base.Vars, o.Vars)
+	out.Links = mergeLinks(base.Links, o.Links)
+	return out
+}
+
+// mergeVars merges variables (or query parameters) key by key; an empty
+// overlay value deletes.
+func mergeVars(base, o map[string]string) map[string]string {
+	if len(base) == 0 && len(o) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(base)+len(o))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range o {
+		if v == "" {
+			delete(out, k)
+		} else {
+			out[k] = v
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// mergeParams merges param declarations by name; overlay params replace
+// inherited ones as a whole.
+func mergeParams(base, o map[string]Param) map[string]Param {
+	if len(base) == 0 && len(o) == 0 {
+		return nil
+	}
+	out := make(map[string]Param, len(base)+len(o))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range o {
+		out[k] = v
+	}
+	return out
+}
+
+// isEmpty reports whether a link carries no data, which in an overlay
+// marks an inherited entry for deletion.
+func (l Link) isEmpty() bool {
+	return l.URL == "" && l.isURLOnly()
+}
+
+// isURLOnly reports whether a link sets nothing besides its URL.
+func (l Link) isURLOnly() bool {
+	return l.Preset == "" && l.Site == "" && l.Project == "" &&
+		l.Pattern == "" && l.OnMissing == "" && l.FallbackURL == "" && l.When == nil &&
+		len(l.Params) == 0 && len(l.Query) == 0 && len(l.Overrides) == 0 &&
+		len(l.Vars) == 0 && len(l.Links) == 0
+}
@@ -0,0 +1,423 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeLayers(t *testing.T, dist, local string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if dist != "" {
+		if err := os.WriteFile(filepath.Join(dir, FileNameDist), []byte(dist), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if local != "" {
+		if err := os.WriteFile(filepath.Join(dir, FileName), []byte(local), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoad_LocalOverlaysDist(t *testing.T) {
+	dir := writeLayers(t, `
+name: Team
+environments:
+  prod: https://example.com
+  staging: https://staging.example.com
+tools:
+  jira:
+    url: https://jira.example.com
+    pattern: "PROJ-\\d+"
+    links:
+      board: /board
+      backlog: /backlog
+  sentry: https://sentry.io
+`, `
+environments:
+  local: https://project.ddev.site
+  staging: https://my-staging.example.com
+tools:
+  jira:
+    links:
+      backlog: ""
+      mine: /mine
+  sentry: ~
+  timer: https://timer.example.com
+`)
+
+	path, err := Find(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Name != "Team" {
+		t.Errorf("name = %q, want inherited Team", cfg.Name)
+	}
+	if len(cfg.Environments) != 3 {
+		t.Errorf("got %d environments, want 3", len(cfg.Environments))
+	}
+	if cfg.Environments["staging"].URL != "https://my-staging.example.com" {
+		t.Errorf("staging = %q, want local override", cfg.Environments["staging"].URL)
+	}
+
+	jira := cfg.Tools["jira"]
+	if jira.URL != "https://jira.example.com" || jira.Pattern != `PROJ-\d+` {
+		t.Errorf("jira = %+v, want inherited url and pattern", jira)
+	}
+	if _, ok := jira.Links["backlog"]; ok {
+		t.Error("backlog sub-link should be deleted")
+	}
+	if jira.Links["board"].URL != "/board" || jira.Links["mine"].URL != "/mine" {
+		t.Errorf("jira links = %v", jira.Links)
+	}
+
+	if _, ok := cfg.Tools["sentry"]; ok {
+		t.Error("sentry should be deleted by null overlay")
+	}
+	if cfg.Tools["timer"].URL != "https://timer.example.com" {
+		t.Error("timer should be added by local layer")
+	}
+
+	if len(cfg.Files) != 2 {
+		t.Errorf("files = %v, want dist and local", cfg.Files)
+	}
+}
+
+func TestLoad_TracksSource(t *testing.T) {
+	dir := writeLayers(t, `
+environments:
+  prod: https://example.com
+`, `
+environments:
+  local: https://project.ddev.site
+`)
+
+	cfg, err := Load(filepath.Join(dir, FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := filepath.Base(cfg.Environments["prod"].Source); got != FileNameDist {
+		t.Errorf("prod source = %q, want dist", got)
+	}
+	if got := filepath.Base(cfg.Environments["local"].Source); got != FileName {
+		t.Errorf("local source = %q, want local", got)
+	}
+}
+
+func TestLoad_DistAlone(t *testing.T) {
+	dir := writeLayers(t, "environments:\n  prod: https://example.com\n", "")
+	cfg, err := Load(filepath.Join(dir, FileNameDist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Files) != 1 {
+		t.Errorf("files = %v, want only dist", cfg.Files)
+	}
+}
+
+func TestLoad_OverlayDeletesEverything(t *testing.T) {
+	dir := writeLayers(t, "environments:\n  prod: https://example.com\n", "environments:\n  prod: ~\n")
+	if _, err := Load(filepath.Join(dir, FileName)); err == nil {
+		t.Error("expected validation error when overlay deletes every link")
+	}
+}
+
+func TestMerge_DoesNotModifyInputs(t *testing.T) {
+	base := &Config{Tools: map[string]Link{
+		"jira": {URL: "https://jira.example.com", Links: map[string]Link{"board": {URL: "/board"}}},
+	}}
+	overlay := &Config{Tools: map[string]Link{
+		"jira": {Links: map[string]Link{"board": {}}},
+	}}
+
+	Merge(base, overlay)
+
+	if base.Tools["jira"].Links["board"].URL != "/board" {
+		t.Error("Merge modified the base config")
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	global := &Config{
+		Environments: map[string]Link{"sandbox": {URL: "https://sandbox.example.com"}},
+		Tools: map[string]Link{
+			"jira": {
+				URL:         "https://jira.example.com/browse/{ticket}",
+				Pattern:     "[A-Z]+-[0-9]+",
+				OnMissing:   OnMissingFallback,
+				FallbackURL: "https://jira.example.com",
+				Links:       map[string]Link{"board": {URL: "/board"}},
+			},
+			"wiki": {URL: "https://wiki.example.com"},
+		},
+	}
+	project := &Config{
+		Type:         &ProjectType{Name: "wordpress", AdminPath: "/wp-admin"},
+		Environments: map[string]Link{"prod": {URL: "https://example.com", Source: "/p/.surf-links.yml"}},
+		Tools:        map[string]Link{"jira": {URL: "https://tracker.example.com/{ticket}", Source: "/p/.surf-links.yml"}},
+	}
+
+	got := Overlay(global, project)
+
+	jira := got.Tools["jira"]
+	if jira.URL != "https://tracker.example.com/{ticket}" || jira.Pattern != "" || jira.FallbackURL != "" || jira.Links != nil {
+		t.Errorf("project link should replace the global one as a whole, got %+v", jira)
+	}
+	all := got.AllLinks()
+	if all["wiki"].URL != "https://wiki.example.com" {
+		t.Errorf("wiki = %q, want global link", all["wiki"].URL)
+	}
+	if _, ok := all["sandbox"]; !ok {
+		t.Error("global environment missing")
+	}
+	if _, ok := all["admin sandbox"]; ok {
+		t.Error("global environment should not generate type links")
+	}
+	if all["admin"].URL != "https://example.com/wp-admin" {
+		t.Errorf("admin = %q, want project environment", all["admin"].URL)
+	}
+}
+
+func TestOverlay_ShadowsAcrossCategories(t *testing.T) {
+	global := &Config{
+		Docs: map[string]Link{
+			"wiki":   {URL: "https://wiki.example.com", Links: map[string]Link{"faq": {URL: "/faq"}}},
+			"guides": {URL: "https://guides.example.com"},
+		},
+	}
+	project := &Config{
+		Tools: map[string]Link{"wiki": {URL: "https://project.example.com/wiki"}},
+	}
+
+	got := Overlay(global, project)
+	if err := got.Validate(); err != nil {
+		t.Fatalf("Validate() error: %v", err)
+	}
+	if warnings := got.Collisions(); len(warnings) != 0 {
+		t.Errorf("Collisions() = %v, want none", warnings)
+	}
+	all := got.AllLinks()
+	if all["wiki"].URL != "https://project.example.com/wiki" {
+		t.Errorf("wiki = %q, want project link", all["wiki"].URL)
+	}
+	if _, ok := all["wiki faq"]; ok {
+		t.Error("sub-links of the shadowed global link should be gone")
+	}
+	if all["guides"].URL != "https://guides.example.com" {
+		t.Errorf("guides = %q, want global link", all["guides"].URL)
+	}
+}
+
+func TestLoad_InheritMergesAncestors(t *testing.T) {
+	root := t.TempDir()
+	app := filepath.Join(root, "apps", "shop")
+	if err := os.MkdirAll(app, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCfg := `
+name: Monorepo
+tools:
+  jira:
+    url: https://jira.example.com/browse/{ticket}
+    pattern: "PROJ-\\d+"
+  sentry: https://sentry.io/org
+`
+	appCfg := `
+inherit: true
+name: Shop
+environments:
+  prod: https://shop.example.com
+tools:
+  sentry: https://sentry.io/org/shop
+`
+	if err := os.WriteFile(filepath.Join(root, FileName), []byte(rootCfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	appPath := filepath.Join(app, FileName)
+	if err := os.WriteFile(appPath, []byte(appCfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(appPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "Shop" {
+		t.Errorf("name = %q, want closest file to win", cfg.Name)
+	}
+	if cfg.Tools["jira"].Pattern != `PROJ-\d+` {
+		t.Error("jira should be inherited from the root config")
+	}
+	if cfg.Tools["sentry"].URL != "https://sentry.io/org/shop" {
+		t.Errorf("sentry = %q, want app override", cfg.Tools["sentry"].URL)
+	}
+	if got := cfg.Tools["jira"].Dir(); got != root {
+		t.Errorf("jira dir = %q, want %q", got, root)
+	}
+	if len(cfg.Files) != 2 {
+		t.Errorf("files = %v", cfg.Files)
+	}
+}
+
+func TestLoad_WithoutInheritStopsAtClosest(t *testing.T) {
+	root := t.TempDir()
+	app := filepath.Join(root, "app")
+	if err := os.MkdirAll(app, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, FileName), []byte("tools:\n  jira: https://jira.example.com\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	appPath := filepath.Join(app, FileName)
+	if err := os.WriteFile(appPath, []byte("environments:\n  prod: https://example.com\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(appPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cfg.Tools["jira"]; ok {
+		t.Error("jira should not be inherited without inherit: true")
+	}
+}
+
+func TestLoad_InheritWithoutAncestor(t *testing.T) {
+	dir := writeLayers(t, "", "inherit: true\nenvironments:\n  prod: https://example.com\n")
+	if _, err := Load(filepath.Join(dir, FileName)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad_Include(t *testing.T) {
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "shared")
+	project := filepath.Join(dir, "project")
+	for _, d := range []string{shared, project} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := map[string]string{
+		filepath.Join(shared, "company.yml"): `
+include: [sentry.yml]
+tools:
+  jira:
+    url: https://jira.example.com/browse/{ticket}
+    pattern: "PROJ-\\d+"
+  confluence: https://wiki.example.com
+`,
+		filepath.Join(shared, "sentry.yml"): `
+tools:
+  sentry: https://sentry.io/org
+`,
+		filepath.Join(project, FileName): `
+include:
+  - ../shared/company.yml
+environments:
+  prod: https://example.com
+tools:
+  jira:
+    pattern: "SHOP-\\d+"
+`,
+	}
+	for path, data := range files {
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg, err := Load(filepath.Join(project, FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Tools["jira"].Pattern != `SHOP-\d+` {
+		t.Errorf("jira pattern = %q, want own key to override include", cfg.Tools["jira"].Pattern)
+	}
+	if cfg.Tools["jira"].URL != "https://jira.example.com/browse/{ticket}" {
+		t.Errorf("jira url = %q, want included url", cfg.Tools["jira"].URL)
+	}
+	if cfg.Tools["sentry"].URL != "https://sentry.io/org" {
+		t.Error("nested include should provide sentry")
+	}
+	if got := filepath.Base(cfg.Tools["confluence"].Source); got != "company.yml" {
+		t.Errorf("confluence source = %q", got)
+	}
+	if len(cfg.Files) != 3 {
+		t.Errorf("files = %v", cfg.Files)
+	}
+}
+
+func TestLoad_IncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, FileName)
+	b := filepath.Join(dir, "b.yml")
+	if err := os.WriteFile(a, []byte("include: [b.yml]\nenvironments:\n  prod: https://example.com\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte("include: ["+FileName+"]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Load(a)
+	if err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Fatalf("expected include cycle error, got %v", err)
+	}
+}
+
+func TestLoad_IncludeErrorsNameFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, FileName)
+	broken := filepath.Join(dir, "broken.yml")
+	if err := os.WriteFile(path, []byte("include: [missing.yml]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), path) || !strings.Contains(err.Error(), "missing.yml") {
+		t.Errorf("missing include error = %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("include: [broken.yml]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(broken, []byte("tools:\n  jira:\n    pattern: x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = Load(path)
+	if err == nil || !strings.Contains(err.Error(), broken) {
+		t.Errorf("validation error = %v, want it to name %s", err, broken)
+	}
+}
+
+func TestMerge_Vars(t *testing.T) {
+	base := &Config{Environments: map[string]Link{
+		"prod": {URL: "https://example.com", Vars: map[string]string{"sentry_env": "production", "region": "eu"}},
+	}}
+	overlay := &Config{Environments: map[string]Link{
+		"prod": {Vars: map[string]string{"region": "us"}},
+	}}
+
+	merged := Merge(base, overlay)
+	prod := merged.Environments["prod"]
+	if prod.URL != "https://example.com" {
+		t.Errorf("URL = %q, want base URL kept", prod.URL)
+	}
+	if prod.Vars["sentry_env"] != "production" || prod.Vars["region"] != "us" {
+		t.Errorf("vars = %v, want overlay merged over base", prod.Vars)
+	}
+	if base.Environments["prod"].Vars["region"] != "eu" {
+		t.Error("merge modified base vars")
+	}
+}
@@ -0,0 +1,246 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// presetOptions are the values a preset link is configured with. Site is
+// the hosted account (expanded to a host by the preset) or a full host for
+// self-hosted instances; Project is the project key or repository path.
+type presetOptions struct {
+	Site    string
+	Project string
+}
+
+// preset generates a tool link from its options. Required lists the
+// options the preset cannot do without.
+type preset struct {
+	Required []string
+	Build    func(o presetOptions) Link
+}
+
+// presets maps preset names to the links they generate.
+var presets = map[string]preset{
+	"bitbucket": {Build: func(o presetOptions) Link {
+		return Link{
+			URL: "https://" + orDefault(o.Site, "bitbucket.org") + "/" + orDefault(o.Project, "{owner}/{repo}"),
+			Links: map[string]Link{
+				"prs":       {URL: "/pull-requests"},
+				"pipelines": {URL: "/pipelines"},
+				"branches":  {URL: "/branches"},
+				"branch":    {URL: "/src/{branch}"},
+				"commit":    {URL: "/commits/{commit}"},
+			},
+		}
+	}},
+	"github": {Build: func(o presetOptions) Link {
+		return Link{
+			URL: "https://" + orDefault(o.Site, "github.com") + "/" + orDefault(o.Project, "{owner}/{repo}"),
+			Links: map[string]Link{
+				"prs":      {URL: "/pulls"},
+				"issues":   {URL: "/issues"},
+				"issue":    {URL: "/issues/{ticket}"},
+				"actions":  {URL: "/actions"},
+				"releases": {URL: "/releases"},
+				"branch":   {URL: "/tree/{branch}"},
+				"commit":   {URL: "/commit/{commit}"},
+			},
+		}
+	}},
+	"gitlab": {Build: func(o presetOptions) Link {
+		return Link{
+			URL: "https://" + orDefault(o.Site, "gitlab.com") + "/" + orDefault(o.Project, "{owner}/{repo}"),
+			Links: map[string]Link{
+				"mrs":       {URL: "/-/merge_requests"},
+				"issues":    {URL: "/-/issues"},
+				"issue":     {URL: "/-/issues/{ticket}"},
+				"pipelines": {URL: "/-/pipelines"},
+				"branch":    {URL: "/-/tree/{branch}"},
+				"commit":    {URL: "/-/commit/{commit}"},
+			},
+		}
+	}},
+	"jira": {Required: []string{"site", "project"}, Build: func(o presetOptions) Link {
+		base := "https://" + siteHost(o.Site, ".atlassian.net")
+		project := base + "/jira/software/projects/" + o.Project
+		return Link{
+			URL:         base + "/browse/{ticket}",
+			Pattern:     ticketPattern(o.Project),
+			OnMissing:   OnMissingFallback,
+			FallbackURL: base + "/browse/" + o.Project,
+			Links: map[string]Link{
+				"board":   {URL: project + "/board"},
+				"backlog": {URL: project + "/backlog"},
+				"search": {
+					URL:   base + "/issues/",
+					Query: map[string]string{"jql": "project = " + o.Project + " AND text ~ \"{query}\""},
+				},
+			},
+		}
+	}},
+	"linear": {Required: []string{"site", "project"}, Build: func(o presetOptions) Link {
+		base := "https://linear.app/" + o.Site
+		team := base + "/team/" + o.Project
+		return Link{
+			URL:         base + "/issue/{ticket}",
+			Pattern:     ticketPattern(o.Project),
+			OnMissing:   OnMissingFallback,
+			FallbackURL: team + "/active",
+			Links: map[string]Link{
+				"active":  {URL: team + "/active"},
+				"backlog": {URL: team + "/backlog"},
+				"triage":  {URL: team + "/triage"},
+			},
+		}
+	}},
+	"sentry": {Required: []string{"site", "project"}, Build: func(o presetOptions) Link {
+		base := "https://" + siteHost(o.Site, ".sentry.io")
+		return Link{
+			URL: base + "/projects/" + o.Project + "/",
+			Links: map[string]Link{
+				"issues":   {URL: base + "/issues/"},
+				"releases": {URL: base + "/releases/"},
+				"search":   {URL: base + "/issues/", Query: map[string]string{"query": "{query}"}},
+			},
+		}
+	}},
+	"youtrack": {Required: []string{"site", "project"}, Build: func(o presetOptions) Link {
+		base := "https://" + siteHost(o.Site, ".youtrack.cloud")
+		return Link{
+			URL:         base + "/issue/{ticket}",
+			Pattern:     ticketPattern(o.Project),
+			OnMissing:   OnMissingFallback,
+			FallbackURL: base + "/issues/" + o.Project,
+			Links: map[string]Link{
+				"issues": {URL: base + "/issues/" + o.Project},
+				"boards": {URL: base + "/agiles"},
+				"search": {URL: base + "/issues", Query: map[string]string{"q": "project: " + o.Project + " {query}"}},
+			},
+		}
+	}},
+}
+
+// PresetNames returns a sorted list of all tool preset names.
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// expandPreset returns the link generated by its preset, with every field
+// the link sets itself layered on top. Links without a preset, or with one
+// that fails validation, are returned unchanged.
+func (l Link) expandPreset() Link {
+	if l.Preset == "" || l.validatePreset() != nil {
+		return l
+	}
+	generated := presets[l.Preset].Build(presetOptions{Site: l.Site, Project: l.Project})
+	generated.Source = l.Source
+	own := l
+	own.Preset, own.Site, own.Project = "", "", ""
+	return mergeLink(generated, own)
+}
+
+// validatePreset checks that the preset exists and its required options
+// are set.
+func (l Link) validatePreset() error {
+	if l.Preset == "" {
+		if l.Site != "" || l.Project != "" {
+			return fmt.Errorf("site and project require a preset")
+		}
+		return nil
+	}
+	p, ok := presets[l.Preset]
+	if !ok {
+		return fmt.Errorf("unknown preset %q (want one of %s)", l.Preset, strings.Join(PresetNames(), ", "))
+	}
+	values := map[string]string{"site": l.Site, "project": l.Project}
+	for _, opt := range p.Required {
+		if values[opt] == "" {
+			return fmt.Errorf("preset %q requires %s", l.Preset, opt)
+		}
+	}
+	return nil
+}
+
+// ExpandPresets replaces every tool link that sets a preset with the link
+// the preset generates, once, after all layers are merged. It fails on an
+// unknown preset, missing options, or a preset outside tools:.
+func (c *Config) ExpandPresets() error {
+	tools, err := expandPresets(c.Tools)
+	if err != nil {
+		return err
+	}
+	for _, cat := range append([]Category{
+		{Name: "environments", Links: c.Environments},
+		{Name: "docs", Links: c.Docs},
+	}, c.Custom...) {
+		if err := rejectPresets(cat.Name, cat.Links); err != nil {
+			return err
+		}
+	}
+	c.Tools = tools
+	return nil
+}
+
+// expandPresets expands the presets of links and their sub-links.
+func expandPresets(links map[string]Link) (map[string]Link, error) {
+	if links == nil {
+		return nil, nil
+	}
+	out := make(map[string]Link, len(links))
+	for name, link := range links {
+		if err := link.validatePreset(); err != nil {
+			return nil, fmt.Errorf("%slink %q: %w", sourcePrefix(link.Source), name, err)
+		}
+		link = link.expandPreset()
+		subs, err := expandPresets(link.Links)
+		if err != nil {
+			return nil, err
+		}
+		link.Links = subs
+		out[name] = link
+	}
+	return out, nil
+}
+
+// rejectPresets reports a link of a category other than tools that sets a
+// preset or its options.
+func rejectPresets(category string, links map[string]Link) error {
+	for name, link := range links {
+		if link.Preset != "" || link.Site != "" || link.Project != "" {
+			return fmt.Errorf("%slink %q: presets are only supported under tools, not %s", sourcePrefix(link.Source), name, category)
+		}
+		if err := rejectPresets(category, link.Links); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// siteHost expands a hosted account name to its host, e.g. "acme" to
+// "acme.atlassian.net"; a site containing a dot is already a host.
+func siteHost(site, suffix string) string {
+	if strings.Contains(site, ".") {
+		return site
+	}
+	return site + suffix
+}
+
+// ticketPattern matches issue keys of a project, e.g. PROJ-123.
+func ticketPattern(project string) string {
+	return regexp.QuoteMeta(project) + "-[0-9]+"
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
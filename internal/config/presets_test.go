@@ -0,0 +1,159 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLink_ExpandPreset(t *testing.T) {
+	tests := []struct {
+		name        string
+		link        Link
+		wantURL     string
+		wantPattern string
+		sub         string
+		wantSubURL  string
+	}{
+		{"jira cloud", Link{Preset: "jira", Site: "acme", Project: "PROJ"},
+			"https://acme.atlassian.net/browse/{ticket}", "PROJ-[0-9]+",
+			"board", "https://acme.atlassian.net/jira/software/projects/PROJ/board"},
+		{"jira self-hosted", Link{Preset: "jira", Site: "jira.acme.com", Project: "OPS"},
+			"https://jira.acme.com/browse/{ticket}", "OPS-[0-9]+",
+			"backlog", "https://jira.acme.com/jira/software/projects/OPS/backlog"},
+		{"github from remote", Link{Preset: "github"},
+			"https://github.com/{owner}/{repo}", "",
+			"actions", "/actions"},
+		{"github explicit", Link{Preset: "github", Project: "acme/shop"},
+			"https://github.com/acme/shop", "",
+			"prs", "/pulls"},
+		{"gitlab self-hosted", Link{Preset: "gitlab", Site: "git.acme.com", Project: "web/shop"},
+			"https://git.acme.com/web/shop", "",
+			"mrs", "/-/merge_requests"},
+		{"bitbucket", Link{Preset: "bitbucket"},
+			"https://bitbucket.org/{owner}/{repo}", "",
+			"pipelines", "/pipelines"},
+		{"linear", Link{Preset: "linear", Site: "acme", Project: "ENG"},
+			"https://linear.app/acme/issue/{ticket}", "ENG-[0-9]+",
+			"backlog", "https://linear.app/acme/team/ENG/backlog"},
+		{"sentry", Link{Preset: "sentry", Site: "acme", Project: "shop"},
+			"https://acme.sentry.io/projects/shop/", "",
+			"issues", "https://acme.sentry.io/issues/"},
+		{"youtrack", Link{Preset: "youtrack", Site: "acme", Project: "SUP"},
+			"https://acme.youtrack.cloud/issue/{ticket}", "SUP-[0-9]+",
+			"issues", "https://acme.youtrack.cloud/issues/SUP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.link.expandPreset()
+			if got.URL != tt.wantURL {
+				t.Errorf("URL = %q, want %q", got.URL, tt.wantURL)
+			}
+			if got.Pattern != tt.wantPattern {
+				t.Errorf("Pattern = %q, want %q", got.Pattern, tt.wantPattern)
+			}
+			if sub := got.Links[tt.sub]; sub.URL != tt.wantSubURL {
+				t.Errorf("%s = %q, want %q", tt.sub, sub.URL, tt.wantSubURL)
+			}
+			if got.Preset != "" {
+				t.Error("expanded link still carries its preset")
+			}
+		})
+	}
+}
+
+func TestPresets_Valid(t *testing.T) {
+	for _, name := range PresetNames() {
+		link := Link{Preset: name, Site: "acme", Project: "PROJ"}.expandPreset()
+		if link.URL == "" {
+			t.Errorf("%s: no url", name)
+		}
+		if err := link.validate(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		for sub, l := range link.Links {
+			if err := link.child(l).validate(); err != nil {
+				t.Errorf("%s %s: %v", name, sub, err)
+			}
+		}
+	}
+}
+
+func TestLoad_PresetOverrides(t *testing.T) {
+	cfg, err := parseYAML(t, `
+tools:
+  jira:
+    preset: jira
+    site: acme
+    project: PROJ
+    pattern: "(PROJ|OPS)-[0-9]+"
+    links:
+      board: https://acme.atlassian.net/jira/software/projects/PROJ/boards/7
+      backlog: ~
+      sprint: https://acme.atlassian.net/jira/software/projects/PROJ/boards/7/sprint
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	all := cfg.AllLinks()
+	if got := all["jira"].URL; got != "https://acme.atlassian.net/browse/{ticket}" {
+		t.Errorf("jira = %q", got)
+	}
+	if got := all["jira"].Pattern; got != "(PROJ|OPS)-[0-9]+" {
+		t.Errorf("pattern = %q, want the override", got)
+	}
+	if got := all["jira board"].URL; !strings.HasSuffix(got, "/boards/7") {
+		t.Errorf("board = %q, want the override", got)
+	}
+	if _, ok := all["jira backlog"]; ok {
+		t.Error("~ should remove the preset's backlog sub-link")
+	}
+	if _, ok := all["jira sprint"]; !ok {
+		t.Error("extra sub-link missing")
+	}
+	if _, ok := all["jira search"]; !ok {
+		t.Error("untouched preset sub-link missing")
+	}
+	if all["jira"].Source == "" {
+		t.Error("expanded preset lost its source")
+	}
+}
+
+func TestMerge_PresetOptions(t *testing.T) {
+	base := &Config{Tools: map[string]Link{"jira": {Preset: "jira", Site: "acme", Project: "PROJ"}}}
+	overlay := &Config{Tools: map[string]Link{"jira": {Project: "OPS"}}}
+
+	merged := Merge(base, overlay)
+	if err := merged.ExpandPresets(); err != nil {
+		t.Fatal(err)
+	}
+	got := merged.AllLinks()["jira"]
+	if got.Pattern != "OPS-[0-9]+" || got.URL != "https://acme.atlassian.net/browse/{ticket}" {
+		t.Errorf("jira = %+v", got)
+	}
+}
+
+func TestValidate_Presets(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{"unknown", "tools:\n  a:\n    preset: trello\n", `unknown preset "trello"`},
+		{"missing project", "tools:\n  a:\n    preset: jira\n    site: acme\n", "requires project"},
+		{"options without preset", "tools:\n  a:\n    url: https://a.example.com\n    site: acme\n", "require a preset"},
+		{"preset on environment", "environments:\n  prod:\n    preset: github\n", "only supported under tools"},
+		{"preset on docs", "docs:\n  wiki:\n    url: https://wiki.example.com\n    preset: github\n", "only supported under tools"},
+		{"preset on custom category", "ci:\n  gh:\n    preset: github\n", "only supported under tools"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseYAML(t, tt.yaml)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
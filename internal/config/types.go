@@ -1,120 +1,258 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"sort"
 
 	"gopkg.in/yaml.v3"
 )
 
-// standardTypes maps project type names to their admin paths.
-var standardTypes = map[string]string{
-	"craft":             "/admin",
-	"drupal":            "/admin",
-	"laravel":           "/admin",
-	"magento":           "/admin",
-	"shopware":          "/admin",
-	"typo3":             "/typo3",
-	"wordpress":         "/wp-admin",
-	"wordpress-bedrock": "/wp/wp-admin",
+// standardTypes maps project type names to the links they generate.
+var standardTypes = map[string]ProjectType{
+	"craft": {AdminPath: "/admin", Links: map[string]string{
+		"login":     "/admin/login",
+		"utilities": "/admin/utilities",
+		"updates":   "/admin/utilities/updates",
+	}},
+	"drupal": {AdminPath: "/admin", Links: map[string]string{
+		"login":   "/user/login",
+		"status":  "/admin/reports/status",
+		"modules": "/admin/modules",
+		"cache":   "/admin/config/development/performance",
+	}},
+	"laravel": {AdminPath: "/admin", Links: map[string]string{
+		"horizon":   "/horizon",
+		"telescope": "/telescope",
+		"nova":      "/nova",
+	}},
+	"magento": {AdminPath: "/admin", Links: map[string]string{
+		"cache": "/admin/admin/cache",
+	}},
+	"shopware": {AdminPath: "/admin", Links: map[string]string{
+		"api-docs":       "/api/_info/swagger.html",
+		"store-api-docs": "/store-api/_info/swagger.html",
+	}},
+	"typo3": {AdminPath: "/typo3", Links: map[string]string{
+		"install":     "/typo3/install.php",
+		"maintenance": "/typo3/module/tools/maintenance",
+	}},
+	"wordpress": {AdminPath: "/wp-admin", Links: map[string]string{
+		"login":       "/wp-login.php",
+		"plugins":     "/wp-admin/plugins.php",
+		"updates":     "/wp-admin/update-core.php",
+		"site-health": "/wp-admin/site-health.php",
+	}},
+	"wordpress-bedrock": {AdminPath: "/wp/wp-admin", Links: map[string]string{
+		"login":       "/wp/wp-login.php",
+		"plugins":     "/wp/wp-admin/plugins.php",
+		"updates":     "/wp/wp-admin/update-core.php",
+		"site-health": "/wp/wp-admin/site-health.php",
+	}},
 }
 
-// ProjectType represents a project type that generates admin links.
+// registeredTypes holds user-defined project types (see RegisterType). It
+// is not safe for concurrent use: types are registered once at startup,
+// before any config is loaded.
+var registeredTypes = map[string]ProjectType{}
+
+// RegisterType makes a user-defined project type available by name, as if
+// it were a standard type; one with a standard name replaces it. Types must
+// be registered before configs using them are loaded.
+func RegisterType(pt ProjectType) error {
+	if pt.Name == "" {
+		return fmt.Errorf("project type requires a name")
+	}
+	if len(pt.paths()) == 0 {
+		return fmt.Errorf("project type %q requires admin_path or links", pt.Name)
+	}
+	registeredTypes[pt.Name] = pt
+	return nil
+}
+
+// RegisterTypes registers the types of a types: mapping in name order, each
+// named after its key. An entry is decoded only once the ones before it are
+// registered, so it may refer to them by name. Broken entries are skipped
+// and reported together in the returned error.
+func RegisterTypes(types map[string]yaml.Node) error {
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		node := types[name]
+		var pt ProjectType
+		if err := node.Decode(&pt); err != nil {
+			errs = append(errs, fmt.Errorf("project type %q: %w", name, err))
+			continue
+		}
+		pt.Name = name
+		if err := RegisterType(pt); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// lookupType finds a registered or standard project type by name.
+func lookupType(name string) (ProjectType, bool) {
+	if pt, ok := registeredTypes[name]; ok {
+		return pt, true
+	}
+	pt, ok := standardTypes[name]
+	return pt, ok
+}
+
+// LoadTypes reads the types: mapping of a shared types file, keyed by type
+// name, for RegisterTypes. File is resolved like an include: entry relative
+// to from.
+func LoadTypes(from, file string) (map[string]yaml.Node, error) {
+	path, err := includePath(from, file)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw struct {
+		Types map[string]yaml.Node `yaml:"types"`
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return raw.Types, nil
+}
+
+// ProjectType represents a project type that generates links for every
+// environment. Links maps link names to paths below the environment URL;
+// AdminPath is the path of the "admin" link, unless Links sets one.
 type ProjectType struct {
 	Name      string
 	AdminPath string
+	Links     map[string]string
+}
+
+// customType is the mapping form of a ProjectType.
+type customType struct {
+	Name      string            `yaml:"name"`
+	AdminPath string            `yaml:"admin_path,omitempty"`
+	Links     map[string]string `yaml:"links,omitempty"`
 }
 
 // UnmarshalYAML supports both a standard type name (string) and a custom
-// mapping with an admin_path field.
+// mapping with links and/or an admin_path field.
 func (pt *ProjectType) UnmarshalYAML(value *yaml.Node) error {
 	if value.Kind == yaml.ScalarNode {
-		name := value.Value
-		path, ok := standardTypes[name]
-		if !ok {
-			return fmt.Errorf("unknown project type %q", name)
+		std, err := NewStandardType(value.Value)
+		if err != nil {
+			return err
 		}
-		pt.Name = name
-		pt.AdminPath = path
+		*pt = *std
 		return nil
 	}
 
-	// Custom type: {name: ..., admin_path: ...}
-	var raw struct {
-		Name      string `yaml:"name"`
-		AdminPath string `yaml:"admin_path"`
-	}
+	// Custom type: {name: ..., admin_path: ..., links: {name: path}}
+	var raw customType
 	if err := value.Decode(&raw); err != nil {
 		return err
 	}
-	if raw.AdminPath == "" {
-		return fmt.Errorf("custom project type requires admin_path")
+	if raw.AdminPath == "" && len(raw.Links) == 0 {
+		return fmt.Errorf("custom project type requires admin_path or links")
 	}
-	pt.Name = raw.Name
-	pt.AdminPath = raw.AdminPath
+	*pt = ProjectType(raw)
 	return nil
 }
 
-// StandardTypeNames returns a sorted list of all standard type names.
+// StandardTypeNames returns a sorted list of all standard and registered
+// type names.
 func StandardTypeNames() []string {
-	names := make([]string, 0, len(standardTypes))
+	names := make([]string, 0, len(standardTypes)+len(registeredTypes))
 	for name := range standardTypes {
 		names = append(names, name)
 	}
+	for name := range registeredTypes {
+		if _, ok := standardTypes[name]; !ok {
+			names = append(names, name)
+		}
+	}
 	sort.Strings(names)
 	return names
 }
 
-// NewStandardType creates a ProjectType from a known standard name.
+// NewStandardType creates a ProjectType from a known standard or
+// registered name.
 func NewStandardType(name string) (*ProjectType, error) {
-	path, ok := standardTypes[name]
+	std, ok := lookupType(name)
 	if !ok {
 		return nil, fmt.Errorf("unknown project type %q", name)
 	}
-	return &ProjectType{Name: name, AdminPath: path}, nil
+	links := make(map[string]string, len(std.Links))
+	for k, v := range std.Links {
+		links[k] = v
+	}
+	return &ProjectType{Name: name, AdminPath: std.AdminPath, Links: links}, nil
 }
 
-// MarshalYAML writes a standard type as a scalar string,
-// or a custom type as a mapping with name and admin_path.
+// MarshalYAML writes a standard or registered type as a scalar string,
+// or a custom type as a mapping with name, admin_path, and links.
 func (pt ProjectType) MarshalYAML() (interface{}, error) {
-	if _, ok := standardTypes[pt.Name]; ok {
+	if _, ok := lookupType(pt.Name); ok {
 		return pt.Name, nil
 	}
-	return struct {
-		Name      string `yaml:"name"`
-		AdminPath string `yaml:"admin_path"`
-	}{pt.Name, pt.AdminPath}, nil
+	return customType(pt), nil
+}
+
+// paths returns the generated link names mapped to their paths, with
+// AdminPath listed as "admin".
+func (pt *ProjectType) paths() map[string]string {
+	paths := make(map[string]string, len(pt.Links)+1)
+	if pt.AdminPath != "" {
+		paths["admin"] = pt.AdminPath
+	}
+	for name, path := range pt.Links {
+		if path != "" {
+			paths[name] = path
+		}
+	}
+	return paths
 }
 
-// GenerateLinks creates admin links for each environment.
-// Returns a map of link names to Links:
-//   - "admin" → default environment (first alphabetically)
-//   - "admin <env>" → per-environment admin links
+// GenerateLinks creates the type's links for each environment.
+// Returns a map of link names to Links, for every name in paths:
+//   - "<name>" → default environment (first alphabetically)
+//   - "<name> <env>" → per-environment links
 func (pt *ProjectType) GenerateLinks(environments map[string]Link) map[string]Link {
 	if pt == nil || len(environments) == 0 {
 		return nil
 	}
 
 	links := make(map[string]Link)
+	defaultEnv := environments[DefaultEnvironment(environments)]
 
-	// Sort environment names for deterministic default
-	envNames := make([]string, 0, len(environments))
-	for name := range environments {
-		envNames = append(envNames, name)
+	for link, path := range pt.paths() {
+		for name, env := range environments {
+			links[link+" "+name] = Link{URL: JoinURL(env.URL, path), Source: env.Source}
+		}
+		// Default "<name>" → first environment alphabetically
+		links[link] = Link{URL: JoinURL(defaultEnv.URL, path), Source: defaultEnv.Source}
 	}
-	sort.Strings(envNames)
 
-	for _, name := range envNames {
-		env := environments[name]
-		adminURL := env.URL + pt.AdminPath
-		links["admin "+name] = Link{URL: adminURL}
-	}
+	return links
+}
 
-	// Default "admin" → first environment alphabetically
-	if len(envNames) > 0 {
-		defaultEnv := environments[envNames[0]]
-		links["admin"] = Link{URL: defaultEnv.URL + pt.AdminPath}
+// DefaultEnvironment returns the name of the environment used when none is
+// given: the first one alphabetically, or "" when there are none.
+func DefaultEnvironment(environments map[string]Link) string {
+	var first string
+	for name := range environments {
+		if first == "" || name < first {
+			first = name
+		}
 	}
-
-	return links
+	return first
 }
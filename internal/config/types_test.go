@@ -1,6 +1,9 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"gopkg.in/yaml.v3"
@@ -168,3 +171,177 @@ func TestProjectType_GenerateLinks_Nil(t *testing.T) {
 		t.Error("expected nil for nil ProjectType")
 	}
 }
+
+func TestProjectType_UnmarshalYAML_CustomLinks(t *testing.T) {
+	data := `
+name: acme-symfony
+admin_path: /backend
+links:
+  profiler: /_profiler
+  login: /backend/login
+`
+	var pt ProjectType
+	if err := yaml.Unmarshal([]byte(data), &pt); err != nil {
+		t.Fatal(err)
+	}
+	links := pt.GenerateLinks(map[string]Link{"prod": {URL: "https://acme.com"}})
+	for name, want := range map[string]string{
+		"admin":         "https://acme.com/backend",
+		"profiler":      "https://acme.com/_profiler",
+		"login prod":    "https://acme.com/backend/login",
+		"profiler prod": "https://acme.com/_profiler",
+	} {
+		if got := links[name].URL; got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	out, err := yaml.Marshal(pt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back ProjectType
+	if err := yaml.Unmarshal(out, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.AdminPath != "/backend" || back.Links["profiler"] != "/_profiler" {
+		t.Errorf("round trip = %+v", back)
+	}
+}
+
+func TestProjectType_GenerateLinks_StandardSet(t *testing.T) {
+	pt, err := NewStandardType("wordpress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	envs := map[string]Link{
+		"production": {URL: "https://example.com/"},
+		"staging":    {URL: "https://staging.example.com"},
+	}
+
+	links := pt.GenerateLinks(envs)
+	tests := map[string]string{
+		"admin":               "https://example.com/wp-admin",
+		"plugins":             "https://example.com/wp-admin/plugins.php",
+		"site-health staging": "https://staging.example.com/wp-admin/site-health.php",
+		"login production":    "https://example.com/wp-login.php",
+	}
+	for name, want := range tests {
+		if got := links[name].URL; got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestProjectType_LinksOverrideAdminPath(t *testing.T) {
+	pt := &ProjectType{Name: "custom", AdminPath: "/admin", Links: map[string]string{"admin": "/backend"}}
+	links := pt.GenerateLinks(map[string]Link{"prod": {URL: "https://acme.com"}})
+	if got := links["admin"].URL; got != "https://acme.com/backend" {
+		t.Errorf("admin = %q, want links to win over admin_path", got)
+	}
+}
+
+func TestRegisterType(t *testing.T) {
+	t.Cleanup(func() { registeredTypes = map[string]ProjectType{} })
+
+	if err := RegisterType(ProjectType{Name: "acme-symfony", Links: map[string]string{"admin": "/backend", "profiler": "/_profiler"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterType(ProjectType{Name: "empty"}); err == nil {
+		t.Error("expected error for a type without links")
+	}
+
+	var pt ProjectType
+	if err := yaml.Unmarshal([]byte(`acme-symfony`), &pt); err != nil {
+		t.Fatal(err)
+	}
+	links := pt.GenerateLinks(map[string]Link{"prod": {URL: "https://acme.com"}})
+	if got := links["profiler"].URL; got != "https://acme.com/_profiler" {
+		t.Errorf("profiler = %q", got)
+	}
+
+	if val, _ := pt.MarshalYAML(); val != "acme-symfony" {
+		t.Errorf("MarshalYAML = %v, want the registered name", val)
+	}
+
+	names := StandardTypeNames()
+	if names[0] != "acme-symfony" || len(names) != len(standardTypes)+1 {
+		t.Errorf("names = %v", names)
+	}
+}
+
+func TestLoadTypes(t *testing.T) {
+	dir := t.TempDir()
+	data := "types:\n  acme-shop:\n    admin_path: /shop-admin\n  acme-symfony:\n    links:\n      profiler: /_profiler\n"
+	if err := os.WriteFile(filepath.Join(dir, "types.yml"), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	types, err := LoadTypes(filepath.Join(dir, "config.yml"), "types.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(types) != 2 {
+		t.Errorf("types = %v", types)
+	}
+
+	if _, err := LoadTypes(filepath.Join(dir, "config.yml"), "missing.yml"); err == nil {
+		t.Error("expected error for a missing types file")
+	}
+}
+
+func TestRegisterTypes_SkipsBrokenEntries(t *testing.T) {
+	t.Cleanup(func() { registeredTypes = map[string]ProjectType{} })
+
+	var raw struct {
+		Types map[string]yaml.Node `yaml:"types"`
+	}
+	data := `
+types:
+  acme-base:
+    admin_path: /backend
+  acme-shop: acme-base
+  broken:
+    links: [not, a, mapping]
+  unknown: no-such-type
+`
+	if err := yaml.Unmarshal([]byte(data), &raw); err != nil {
+		t.Fatal(err)
+	}
+
+	err := RegisterTypes(raw.Types)
+	if err == nil || !strings.Contains(err.Error(), `"broken"`) || !strings.Contains(err.Error(), `"unknown"`) {
+		t.Errorf("RegisterTypes() = %v, want errors for the broken entries", err)
+	}
+	if _, ok := lookupType("acme-base"); !ok {
+		t.Error("valid type acme-base was not registered")
+	}
+	if pt, ok := lookupType("acme-shop"); !ok || pt.AdminPath != "/backend" {
+		t.Errorf("acme-shop = %+v, %v, want it resolved from acme-base", pt, ok)
+	}
+}
+
+func TestStandardType_ExplicitLinksWin(t *testing.T) {
+	cfg, err := parseYAML(t, `
+type: drupal
+environments:
+  prod: https://example.com
+tools:
+  status: https://status.example.com
+  cache: https://cdn.example.com/purge
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	all := cfg.AllLinks()
+	if all["status"].URL != "https://status.example.com" || all["cache"].URL != "https://cdn.example.com/purge" {
+		t.Errorf("explicit links should keep their names: status = %q, cache = %q", all["status"].URL, all["cache"].URL)
+	}
+	if all["type/status"].URL != "https://example.com/admin/reports/status" {
+		t.Errorf("type/status = %q", all["type/status"].URL)
+	}
+	if c := cfg.Collisions(); len(c) != 0 {
+		t.Errorf("collisions = %v, want none for generated links", c)
+	}
+}
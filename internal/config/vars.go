@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/apermo/apermo-surf/internal/placeholder"
+	"gopkg.in/yaml.v3"
+)
+
+// VarPrefix starts placeholders that refer to a top-level variable,
+// e.g. {vars.namespace}.
+const VarPrefix = "vars."
+
+// Var is a named value available to every link as {vars.<name>}, either
+// a literal value or the output of a shell command. Commands run with
+// sh -c, so they need a POSIX shell, and only from config files the user
+// has trusted (see surf trust).
+// Source is the config file the variable was (last) defined in.
+type Var struct {
+	Value   string `yaml:"value,omitempty"`
+	Command string `yaml:"command,omitempty"`
+	Source  string `yaml:"-"`
+}
+
+func (v *Var) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		v.Value = value.Value
+		return nil
+	}
+
+	// Expanded format: {command: ...}
+	type plain Var
+	return value.Decode((*plain)(v))
+}
+
+// MarshalYAML writes a literal Var as a scalar string.
+func (v Var) MarshalYAML() (interface{}, error) {
+	if v.Command == "" {
+		return v.Value, nil
+	}
+	type plain Var
+	return plain(v), nil
+}
+
+// isEmpty reports whether a variable carries no data, which in an overlay
+// marks an inherited variable for deletion.
+func (v Var) isEmpty() bool {
+	return v.Value == "" && v.Command == ""
+}
+
+// mergeConfigVars merges top-level variables by name; an empty overlay
+// entry deletes the inherited one.
+func mergeConfigVars(base, o map[string]Var) map[string]Var {
+	if len(base) == 0 && len(o) == 0 {
+		return nil
+	}
+	out := make(map[string]Var, len(base)+len(o))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range o {
+		if v.isEmpty() {
+			delete(out, k)
+		} else {
+			out[k] = v
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// validateVars checks that variables set either a value or a command, and
+// that every {vars.<name>} placeholder in the links refers to one of them.
+func (c *Config) validateVars(entries []Entry) error {
+	names := make([]string, 0, len(c.Vars))
+	for name := range c.Vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if v := c.Vars[name]; v.Value != "" && v.Command != "" {
+			return fmt.Errorf("%svar %q sets both value and command", sourcePrefix(v.Source), name)
+		}
+	}
+
+	for _, e := range entries {
+		for _, p := range placeholder.Parse(e.Link.FullURL()) {
+			name, ok := strings.CutPrefix(p.Name, VarPrefix)
+			if !ok {
+				continue
+			}
+			if _, defined := c.Vars[name]; !defined {
+				return fmt.Errorf("%slink %q uses undefined var %q", sourcePrefix(e.Link.Source), e.Short, name)
+			}
+		}
+	}
+	return nil
+}
+
+// sourcePrefix formats source as an error message prefix, or "" when unknown.
+func sourcePrefix(source string) string {
+	if source == "" {
+		return ""
+	}
+	return source + ": "
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVar_UnmarshalYAML(t *testing.T) {
+	cfg, err := parseYAML(t, `
+vars:
+  team: payments
+  namespace:
+    command: kubectl config view --minify -o jsonpath='{..namespace}'
+tools:
+  k8s: https://dash.example.com/{vars.namespace}/{vars.team}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Vars["team"].Value != "payments" {
+		t.Errorf("team = %+v", cfg.Vars["team"])
+	}
+	if !strings.HasPrefix(cfg.Vars["namespace"].Command, "kubectl") {
+		t.Errorf("namespace = %+v", cfg.Vars["namespace"])
+	}
+	if len(cfg.Custom) != 0 {
+		t.Error("vars must not become a custom category")
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestLoad_VarSource(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, FileName)
+	data := "vars:\n  ns:\n    command: echo dev\ntools:\n  k8s: https://k8s.example.com/{vars.ns}\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Vars["ns"].Source != path {
+		t.Errorf("source = %q, want %q", cfg.Vars["ns"].Source, path)
+	}
+}
+
+func TestMerge_ConfigVars(t *testing.T) {
+	base := &Config{Vars: map[string]Var{
+		"ns":   {Command: "kubectl config view"},
+		"team": {Value: "payments"},
+	}}
+	overlay := &Config{Vars: map[string]Var{
+		"ns":   {Value: "dev"},
+		"team": {},
+	}}
+
+	merged := Merge(base, overlay)
+	if merged.Vars["ns"].Value != "dev" || merged.Vars["ns"].Command != "" {
+		t.Errorf("ns = %+v, want overlay value", merged.Vars["ns"])
+	}
+	if _, ok := merged.Vars["team"]; ok {
+		t.Error("team should be deleted by overlay")
+	}
+}
+
+func TestConfig_Validate_Vars(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{
+			name: "undefined var",
+			cfg:  Config{Tools: map[string]Link{"k8s": {URL: "https://k8s.example.com/{vars.ns}"}}},
+			want: `undefined var "ns"`,
+		},
+		{
+			name: "value and command",
+			cfg: Config{
+				Vars:  map[string]Var{"ns": {Value: "dev", Command: "echo dev"}},
+				Tools: map[string]Link{"k8s": {URL: "https://k8s.example.com/{vars.ns}"}},
+			},
+			want: "both value and command",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
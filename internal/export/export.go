@@ -0,0 +1,141 @@
+package export
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/apermo/apermo-surf/internal/config"
+)
+
+// Version is the schema version of the shared export format.
+// Bump it whenever the extension needs to handle a breaking change.
+const Version = 1
+
+// Export is the shared link format consumed by apermo-surf-chrome.
+// Placeholders are left unresolved so the extension can apply its own
+// branch/ticket context.
+type Export struct {
+	Version int    `json:"version"`
+	Name    string `json:"name,omitempty"`
+	Project string `json:"project"`
+	Links   []Link `json:"links"`
+}
+
+// Link is a single flattened link entry in the export.
+// Name is the name surf open accepts; Qualified is always unique.
+type Link struct {
+	Name      string            `json:"name"`
+	Qualified string            `json:"qualified"`
+	Category  string            `json:"category"`
+	URL       string            `json:"url"`
+	Pattern   string            `json:"pattern,omitempty"`
+	Query     map[string]string `json:"query,omitempty"`
+	Vars      map[string]string `json:"vars,omitempty"`
+}
+
+// Changes lists link names that differ between two exports.
+type Changes struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// Empty reports whether there are no changes.
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0
+}
+
+// Build flattens cfg into the shared export format. projectDir is the
+// directory containing the config. Links are sorted by name.
+func Build(cfg *config.Config, projectDir string) Export {
+	e := Export{
+		Version: Version,
+		Name:    cfg.Name,
+		Project: projectDir,
+		Links:   []Link{},
+	}
+
+	for _, entry := range cfg.Entries() {
+		e.Links = append(e.Links, Link{
+			Name:      entry.Name,
+			Qualified: entry.Qualified,
+			Category:  entry.Category,
+			URL:       entry.Link.URL,
+			Pattern:   entry.Link.Pattern,
+			Query:     entry.Link.Query,
+			Vars:      entry.Link.Vars,
+		})
+	}
+	return e
+}
+
+// Diff compares a previous export with the current one by link name.
+// A link counts as changed when any of its fields differ.
+func Diff(prev, cur Export) Changes {
+	old := make(map[string]Link, len(prev.Links))
+	for _, l := range prev.Links {
+		old[l.Name] = l
+	}
+
+	var c Changes
+	for _, l := range cur.Links {
+		p, ok := old[l.Name]
+		switch {
+		case !ok:
+			c.Added = append(c.Added, l.Name)
+		case !equal(p, l):
+			c.Changed = append(c.Changed, l.Name)
+		}
+		delete(old, l.Name)
+	}
+	for name := range old {
+		c.Removed = append(c.Removed, name)
+	}
+
+	sort.Strings(c.Added)
+	sort.Strings(c.Removed)
+	sort.Strings(c.Changed)
+	return c
+}
+
+// equal reports whether two links have the same fields.
+func equal(a, b Link) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+// Read loads a previously written export. A missing file yields an empty
+// export and no error, so the first push reports every link as added.
+func Read(path string) (Export, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Export{}, nil
+	}
+	if err != nil {
+		return Export{}, err
+	}
+	var e Export
+	if err := json.Unmarshal(data, &e); err != nil {
+		return Export{}, err
+	}
+	return e, nil
+}
+
+// Marshal encodes an export as indented JSON with a trailing newline.
+func Marshal(e Export) ([]byte, error) {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Write encodes e and writes it to path.
+func Write(e Export, path string) error {
+	data, err := Marshal(e)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,144 @@
+package export
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/apermo/apermo-surf/internal/config"
+)
+
+func TestBuild_IncludesGeneratedAndSubLinks(t *testing.T) {
+	cfg := &config.Config{
+		Name: "My Project",
+		Type: &config.ProjectType{Name: "wordpress", AdminPath: "/wp-admin"},
+		Environments: map[string]config.Link{
+			"prod": {URL: "https://example.com"},
+		},
+		Tools: map[string]config.Link{
+			"jira": {
+				URL:     "https://jira.example.com/browse/{ticket}",
+				Pattern: `PROJ-\d+`,
+				Links:   map[string]config.Link{"board": {URL: "/board"}},
+			},
+		},
+	}
+
+	e := Build(cfg, "/project")
+
+	if e.Version != Version {
+		t.Errorf("version = %d, want %d", e.Version, Version)
+	}
+	if e.Name != "My Project" {
+		t.Errorf("name = %q", e.Name)
+	}
+
+	byName := make(map[string]Link)
+	for _, l := range e.Links {
+		byName[l.Name] = l
+	}
+
+	// prod + jira + jira board + admin + admin prod = 5
+	if len(byName) != 5 {
+		t.Fatalf("got %d links, want 5: %v", len(byName), e.Links)
+	}
+	if byName["jira"].Pattern != `PROJ-\d+` {
+		t.Errorf("jira pattern = %q", byName["jira"].Pattern)
+	}
+	if byName["jira board"].URL != "https://jira.example.com/browse/{ticket}/board" {
+		t.Errorf("jira board URL = %q", byName["jira board"].URL)
+	}
+	if byName["jira board"].Qualified != "tools/jira/board" {
+		t.Errorf("jira board qualified = %q", byName["jira board"].Qualified)
+	}
+	if byName["jira board"].Category != "tools" {
+		t.Errorf("jira board category = %q", byName["jira board"].Category)
+	}
+	if byName["admin prod"].Category != config.GeneratedCategory {
+		t.Errorf("admin prod category = %q", byName["admin prod"].Category)
+	}
+}
+
+func TestBuild_ExplicitOverridesGenerated(t *testing.T) {
+	cfg := &config.Config{
+		Type: &config.ProjectType{Name: "wordpress", AdminPath: "/wp-admin"},
+		Environments: map[string]config.Link{
+			"prod": {URL: "https://example.com"},
+		},
+		Tools: map[string]config.Link{
+			"admin": {URL: "https://admin.example.com"},
+		},
+	}
+
+	e := Build(cfg, "/project")
+	byName := make(map[string]Link)
+	for _, l := range e.Links {
+		byName[l.Name] = l
+	}
+	if byName["admin"].URL != "https://admin.example.com" {
+		t.Errorf("admin URL = %q, want explicit link", byName["admin"].URL)
+	}
+	if byName["type/admin"].URL != "https://example.com/wp-admin" {
+		t.Errorf("generated admin should stay reachable as type/admin, got %q", byName["type/admin"].URL)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	prev := Export{Links: []Link{
+		{Name: "prod", Category: "environments", URL: "https://example.com"},
+		{Name: "jira", Category: "tools", URL: "https://jira.example.com"},
+		{Name: "wiki", Category: "docs", URL: "https://wiki.example.com"},
+	}}
+	cur := Export{Links: []Link{
+		{Name: "prod", Category: "environments", URL: "https://example.com"},
+		{Name: "jira", Category: "tools", URL: "https://jira.example.com/browse"},
+		{Name: "sentry", Category: "tools", URL: "https://sentry.io"},
+	}}
+
+	c := Diff(prev, cur)
+	if len(c.Added) != 1 || c.Added[0] != "sentry" {
+		t.Errorf("added = %v", c.Added)
+	}
+	if len(c.Changed) != 1 || c.Changed[0] != "jira" {
+		t.Errorf("changed = %v", c.Changed)
+	}
+	if len(c.Removed) != 1 || c.Removed[0] != "wiki" {
+		t.Errorf("removed = %v", c.Removed)
+	}
+}
+
+func TestDiff_NoChanges(t *testing.T) {
+	e := Export{Links: []Link{{Name: "prod", URL: "https://example.com"}}}
+	if c := Diff(e, e); !c.Empty() {
+		t.Errorf("expected no changes, got %+v", c)
+	}
+}
+
+func TestReadWrite_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.json")
+	original := Export{
+		Version: Version,
+		Project: "/project",
+		Links:   []Link{{Name: "prod", Category: "environments", URL: "https://example.com"}},
+	}
+	if err := Write(original, path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := Diff(original, loaded); !c.Empty() {
+		t.Errorf("round trip changed links: %+v", c)
+	}
+}
+
+func TestRead_Missing(t *testing.T) {
+	e, err := Read(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.Links) != 0 {
+		t.Errorf("expected empty export, got %d links", len(e.Links))
+	}
+}
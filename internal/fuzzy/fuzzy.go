@@ -41,3 +41,27 @@ func BestMatch(pattern string, names []string) (string, []string) {
 	}
 	return "", candidates
 }
+
+// MatchArgs resolves command arguments to a link name.
+// With two or more args, the first two are tried as a compound name
+// (e.g. "admin staging") before falling back to the first arg alone.
+// Returns the match, the args left over after the name, and candidates
+// when the first arg is ambiguous. An empty match with nil candidates
+// means nothing matched.
+func MatchArgs(args []string, names []string) (string, []string, []string) {
+	if len(args) == 0 {
+		return "", nil, nil
+	}
+
+	if len(args) >= 2 {
+		if match, _ := BestMatch(args[0]+" "+args[1], names); match != "" {
+			return match, args[2:], nil
+		}
+	}
+
+	match, candidates := BestMatch(args[0], names)
+	if match == "" {
+		return "", nil, candidates
+	}
+	return match, args[1:], nil
+}
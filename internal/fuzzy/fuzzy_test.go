@@ -43,3 +43,24 @@ func TestBestMatch_Ambiguous(t *testing.T) {
 		t.Errorf("expected ambiguous result with candidates: got %q, %v", match, candidates)
 	}
 }
+
+func TestMatchArgs_Compound(t *testing.T) {
+	match, rest, _ := MatchArgs([]string{"admin", "staging"}, []string{"admin", "admin staging", "staging"})
+	if match != "admin staging" || len(rest) != 0 {
+		t.Errorf("got %q, %v; want compound match with no rest", match, rest)
+	}
+}
+
+func TestMatchArgs_NameAndArg(t *testing.T) {
+	match, rest, _ := MatchArgs([]string{"jira", "123"}, names)
+	if match != "jira" || len(rest) != 1 || rest[0] != "123" {
+		t.Errorf("got %q, %v; want jira with rest [123]", match, rest)
+	}
+}
+
+func TestMatchArgs_NoMatch(t *testing.T) {
+	match, rest, candidates := MatchArgs([]string{"zzzzz"}, names)
+	if match != "" || rest != nil || candidates != nil {
+		t.Errorf("expected no match: got %q, %v, %v", match, rest, candidates)
+	}
+}
@@ -14,3 +14,15 @@ func FuzzTicket(f *testing.F) {
 		Ticket(branch, pattern)
 	})
 }
+
+func FuzzParseRemote(f *testing.F) {
+	f.Add("git@github.com:apermo/apermo-surf.git")
+	f.Add("https://github.com/apermo/apermo-surf")
+	f.Add("ssh://git@host:22/a/b/c.git")
+	f.Add("")
+	f.Add("://")
+	f.Add("@:/")
+	f.Fuzz(func(t *testing.T, raw string) {
+		ParseRemote(raw)
+	})
+}
@@ -34,28 +34,112 @@ func Ticket(branch, pattern string) (string, error) {
 // Repo returns the repository name derived from the git remote URL.
 // Returns ("", nil) when not in a git repository or no remote is configured.
 func Repo(dir string) (string, error) {
-	cmd := exec.Command("git", "config", "--get", "remote.origin.url")
-	cmd.Dir = dir
-	out, err := cmd.Output()
-	if err != nil {
-		return "", nil
+	r, err := OriginRemote(dir)
+	return r.Name, err
+}
+
+// Remote holds the parts of a git remote URL.
+type Remote struct {
+	// Host is the forge host, e.g. github.com.
+	Host string
+	// Owner is the org or user, including subgroups (group/subgroup on GitLab).
+	Owner string
+	// Name is the repository name.
+	Name string
+}
+
+// OriginRemote returns the parsed remote.origin.url.
+// Returns (Remote{}, nil) when not in a git repository or no remote is configured.
+func OriginRemote(dir string) (Remote, error) {
+	raw := output(dir, "config", "--get", "remote.origin.url")
+	if raw == "" {
+		return Remote{}, nil
 	}
-	return repoNameFromURL(strings.TrimSpace(string(out))), nil
+	return ParseRemote(raw), nil
 }
 
-// repoNameFromURL extracts the repository name from a git remote URL.
-func repoNameFromURL(raw string) string {
-	raw = strings.TrimSuffix(raw, ".git")
+// ParseRemote splits a git remote URL (https://, ssh://, or scp-like
+// git@host:owner/repo) into host, owner, and repository name.
+func ParseRemote(raw string) Remote {
+	raw = strings.TrimSuffix(strings.TrimSuffix(raw, "/"), ".git")
 
-	// SSH format: git@github.com:user/repo
-	if i := strings.LastIndex(raw, ":"); i != -1 && !strings.Contains(raw, "://") {
-		raw = raw[i+1:]
+	var r Remote
+	path := raw
+	switch {
+	case strings.Contains(raw, "://"):
+		_, rest, _ := strings.Cut(raw, "://")
+		host, p, _ := strings.Cut(rest, "/")
+		if i := strings.LastIndex(host, "@"); i != -1 {
+			host = host[i+1:]
+		}
+		if h, _, ok := strings.Cut(host, ":"); ok {
+			host = h
+		}
+		r.Host, path = host, p
+	case strings.Contains(raw, ":"):
+		// SSH format: git@github.com:user/repo
+		host, p, _ := strings.Cut(raw, ":")
+		if i := strings.LastIndex(host, "@"); i != -1 {
+			host = host[i+1:]
+		}
+		r.Host, path = host, p
 	}
 
-	// Take last path component
-	if i := strings.LastIndex(raw, "/"); i != -1 {
-		raw = raw[i+1:]
+	path = strings.Trim(path, "/")
+	if i := strings.LastIndex(path, "/"); i != -1 {
+		r.Owner, r.Name = path[:i], path[i+1:]
+	} else {
+		r.Name = path
 	}
+	return r
+}
+
+// repoNameFromURL extracts the repository name from a git remote URL.
+func repoNameFromURL(raw string) string {
+	return ParseRemote(raw).Name
+}
 
-	return raw
+// Commit returns the full SHA of HEAD.
+// Returns ("", nil) when not in a git repository.
+func Commit(dir string) (string, error) {
+	return output(dir, "rev-parse", "HEAD"), nil
+}
+
+// ShortCommit returns the abbreviated SHA of HEAD.
+// Returns ("", nil) when not in a git repository.
+func ShortCommit(dir string) (string, error) {
+	return output(dir, "rev-parse", "--short", "HEAD"), nil
+}
+
+// Tag returns the nearest tag reachable from HEAD.
+// Returns ("", nil) when there is no tag.
+func Tag(dir string) (string, error) {
+	return output(dir, "describe", "--tags", "--abbrev=0"), nil
+}
+
+// Upstream returns the upstream of the current branch, e.g. origin/main.
+// Returns ("", nil) when the branch has no upstream.
+func Upstream(dir string) (string, error) {
+	return output(dir, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}"), nil
+}
+
+// DefaultBranch returns the default branch of the origin remote, as
+// recorded in refs/remotes/origin/HEAD (set by git clone or
+// git remote set-head origin --auto).
+// Returns ("", nil) when it is unknown.
+func DefaultBranch(dir string) (string, error) {
+	ref := output(dir, "symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+	return strings.TrimPrefix(ref, "origin/"), nil
+}
+
+// output runs git with args in dir and returns its trimmed output,
+// or "" when the command fails.
+func output(dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
 }
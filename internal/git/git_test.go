@@ -1,6 +1,10 @@
 package git
 
-import "testing"
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
 
 func TestTicket_MatchesPattern(t *testing.T) {
 	ticket, err := Ticket("feature/PROJ-123-add-login", `PROJ-\d+`)
@@ -60,3 +64,68 @@ func TestRepoNameFromURL_NoSuffix(t *testing.T) {
 		t.Errorf("got %q, want apermo-surf", got)
 	}
 }
+
+func TestParseRemote(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want Remote
+	}{
+		{"git@github.com:apermo/apermo-surf.git", Remote{"github.com", "apermo", "apermo-surf"}},
+		{"https://github.com/apermo/apermo-surf.git", Remote{"github.com", "apermo", "apermo-surf"}},
+		{"https://user@gitlab.example.com/group/sub/project", Remote{"gitlab.example.com", "group/sub", "project"}},
+		{"ssh://git@bitbucket.org:7999/team/repo.git", Remote{"bitbucket.org", "team", "repo"}},
+		{"/srv/git/repo.git", Remote{"", "srv/git", "repo"}},
+		{"repo", Remote{"", "", "repo"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			if got := ParseRemote(tt.raw); got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "-q", "-b", "main")
+	run("commit", "-q", "--allow-empty", "-m", "init")
+	run("tag", "v1.2.0")
+	run("remote", "add", "origin", "git@github.com:acme/shop.git")
+
+	commit, _ := Commit(dir)
+	if len(commit) != 40 {
+		t.Errorf("commit = %q", commit)
+	}
+	if short, _ := ShortCommit(dir); short == "" || !strings.HasPrefix(commit, short) {
+		t.Errorf("short commit = %q", short)
+	}
+	if tag, _ := Tag(dir); tag != "v1.2.0" {
+		t.Errorf("tag = %q", tag)
+	}
+	if upstream, _ := Upstream(dir); upstream != "" {
+		t.Errorf("upstream = %q, want none", upstream)
+	}
+	if r, _ := OriginRemote(dir); r.Owner != "acme" || r.Host != "github.com" {
+		t.Errorf("remote = %+v", r)
+	}
+}
+
+func TestMetadata_NotARepo(t *testing.T) {
+	dir := t.TempDir()
+	if commit, err := Commit(dir); commit != "" || err != nil {
+		t.Errorf("Commit = %q, %v", commit, err)
+	}
+	if branch, err := DefaultBranch(dir); branch != "" || err != nil {
+		t.Errorf("DefaultBranch = %q, %v", branch, err)
+	}
+}
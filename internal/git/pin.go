@@ -0,0 +1,116 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Pins returns the links pinned to branch, keyed by name. They are stored
+// in the repository's git config as branch.<branch>.surf-pin entries of the
+// form "<name> <url>". Returns (nil, nil) outside a git repository or when
+// branch is empty.
+func Pins(dir, branch string) (map[string]string, error) {
+	if branch == "" {
+		return nil, nil
+	}
+	lines := output(dir, "config", "--get-all", pinKey(branch))
+	if lines == "" {
+		return nil, nil
+	}
+	pins := make(map[string]string)
+	for _, line := range strings.Split(lines, "\n") {
+		name, url, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if ok && name != "" {
+			pins[name] = strings.TrimSpace(url)
+		}
+	}
+	return pins, nil
+}
+
+// Pin stores url as the pinned link name on branch, replacing an existing
+// pin of the same name.
+func Pin(dir, branch, name, url string) error {
+	if branch == "" || branch == "HEAD" {
+		return fmt.Errorf("not on a branch")
+	}
+	if err := validatePin(name, url); err != nil {
+		return err
+	}
+	return run(dir, "config", "--replace-all", pinKey(branch), name+" "+url, pinPattern(name))
+}
+
+// Unpin removes the named pins from branch, or all of them when no names
+// are given. Unknown names are an error.
+func Unpin(dir, branch string, names ...string) error {
+	pins, err := Pins(dir, branch)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if _, ok := pins[name]; !ok {
+			return fmt.Errorf("no pin %q on %s", name, branch)
+		}
+	}
+	if len(pins) == 0 {
+		return nil
+	}
+	if len(names) == 0 {
+		return run(dir, "config", "--unset-all", pinKey(branch))
+	}
+	for _, name := range names {
+		if err := run(dir, "config", "--unset-all", pinKey(branch), pinPattern(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConfigPath returns the absolute path of the repository's git config file,
+// or "" outside a git repository.
+func ConfigPath(dir string) string {
+	path := output(dir, "rev-parse", "--git-path", "config")
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// validatePin checks that a pin can be stored as a "<name> <url>" entry.
+func validatePin(name, url string) error {
+	if name == "" || strings.ContainsAny(name, " \t\n") {
+		return fmt.Errorf("invalid pin name %q", name)
+	}
+	if url == "" || strings.ContainsAny(url, " \t\n") {
+		return fmt.Errorf("invalid pin URL %q", url)
+	}
+	return nil
+}
+
+func pinKey(branch string) string {
+	return "branch." + branch + ".surf-pin"
+}
+
+// pinPattern matches the entries of the pin name. Git writes entries
+// selected this way in place, under its own config lock.
+func pinPattern(name string) string {
+	return "^" + regexp.QuoteMeta(name) + " "
+}
+
+// run runs git with args in dir, reporting its stderr on failure.
+func run(dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return nil
+}
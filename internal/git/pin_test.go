@@ -0,0 +1,127 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPins(t *testing.T) {
+	dir := t.TempDir()
+	cmd := exec.Command("git", "init", "-q", "-b", "feature/x")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+
+	if err := Pin(dir, "feature/x", "preview", "https://pr-12.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Pin(dir, "feature/x", "sentry", "https://sentry.io/issues/1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Pin(dir, "feature/x", "preview", "https://pr-13.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Pin(dir, "main", "docs", "https://docs.example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := Pins(dir, "feature/x")
+	want := map[string]string{"preview": "https://pr-13.example.com", "sentry": "https://sentry.io/issues/1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Pins = %v, want %v", got, want)
+	}
+
+	if err := Unpin(dir, "feature/x", "preview"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Unpin(dir, "feature/x", "preview"); err == nil {
+		t.Error("unpinning a missing pin should fail")
+	}
+	got, _ = Pins(dir, "feature/x")
+	if !reflect.DeepEqual(got, map[string]string{"sentry": "https://sentry.io/issues/1"}) {
+		t.Errorf("after unpin: %v", got)
+	}
+
+	if err := Unpin(dir, "feature/x"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := Pins(dir, "feature/x"); len(got) != 0 {
+		t.Errorf("after unpin all: %v", got)
+	}
+	if got, _ := Pins(dir, "main"); len(got) != 1 {
+		t.Errorf("other branch pins = %v, want untouched", got)
+	}
+
+	if path := ConfigPath(dir); path != filepath.Join(dir, ".git", "config") {
+		t.Errorf("ConfigPath = %q", path)
+	}
+}
+
+func TestPin_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct{ branch, name, url string }{
+		{"", "preview", "https://example.com"},
+		{"HEAD", "preview", "https://example.com"},
+		{"main", "", "https://example.com"},
+		{"main", "pr preview", "https://example.com"},
+		{"main", "preview", ""},
+	}
+	for _, tt := range tests {
+		if err := Pin(dir, tt.branch, tt.name, tt.url); err == nil {
+			t.Errorf("Pin(%q, %q, %q) succeeded, want error", tt.branch, tt.name, tt.url)
+		}
+	}
+}
+
+func TestPin_InvalidKeepsPins(t *testing.T) {
+	dir := t.TempDir()
+	cmd := exec.Command("git", "init", "-q", "-b", "main")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	if err := Pin(dir, "main", "docs", "https://docs.example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Pin(dir, "main", "b c", "https://b.example.com"); err == nil {
+		t.Fatal("Pin with an invalid name succeeded")
+	}
+	got, _ := Pins(dir, "main")
+	if !reflect.DeepEqual(got, map[string]string{"docs": "https://docs.example.com"}) {
+		t.Errorf("pins = %v, want the previous pins untouched", got)
+	}
+}
+
+func TestPin_SymlinkedConfig(t *testing.T) {
+	dir := t.TempDir()
+	cmd := exec.Command("git", "init", "-q", "-b", "main")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	config := filepath.Join(dir, ".git", "config")
+	shared := filepath.Join(t.TempDir(), "config")
+	if err := os.Rename(config, shared); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(shared, config); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Pin(dir, "main", "docs", "https://docs.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Lstat(config); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("git config is no longer a symlink")
+	}
+	data, _ := os.ReadFile(shared)
+	if !strings.Contains(string(data), "docs https://docs.example.com") {
+		t.Errorf("pin not written to the symlink target:\n%s", data)
+	}
+}
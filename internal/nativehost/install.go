@@ -0,0 +1,93 @@
+package nativehost
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Manifest is the native messaging host manifest Chrome reads to launch surf.
+type Manifest struct {
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	Path           string   `json:"path"`
+	Type           string   `json:"type"`
+	AllowedOrigins []string `json:"allowed_origins"`
+}
+
+// NewManifest builds a manifest launching hostPath for the given extension IDs.
+func NewManifest(hostPath string, extensionIDs []string) Manifest {
+	origins := make([]string, len(extensionIDs))
+	for i, id := range extensionIDs {
+		origins[i] = "chrome-extension://" + id + "/"
+	}
+	return Manifest{
+		Name:           HostName,
+		Description:    "Apermo Surf project links",
+		Path:           hostPath,
+		Type:           "stdio",
+		AllowedOrigins: origins,
+	}
+}
+
+// ManifestDirs returns the per-user NativeMessagingHosts directories for
+// Google Chrome and Chromium below configHome (usually ~/.config).
+func ManifestDirs(configHome string) []string {
+	return []string{
+		filepath.Join(configHome, "google-chrome", "NativeMessagingHosts"),
+		filepath.Join(configHome, "chromium", "NativeMessagingHosts"),
+	}
+}
+
+// WrapperScript returns a shell script that starts surf in native host mode.
+// Chrome passes the caller origin as an argument, which the root command
+// would otherwise treat as a subcommand.
+func WrapperScript(surfPath string) string {
+	return fmt.Sprintf("#!/bin/sh\nexec %s native-host \"$@\"\n", shellQuote(surfPath))
+}
+
+// shellQuote quotes s as a single sh word: wrapped in single quotes, with
+// each embedded single quote closing the quotes, escaped, and reopening them.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Install writes the wrapper script to wrapperPath and a manifest pointing at
+// it into every directory in manifestDirs. Returns the manifest paths written.
+func Install(surfPath, wrapperPath string, extensionIDs []string, manifestDirs []string) ([]string, error) {
+	if runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("native host install is only supported on linux")
+	}
+	if len(extensionIDs) == 0 {
+		return nil, fmt.Errorf("at least one extension ID is required")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(wrapperPath), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(wrapperPath, []byte(WrapperScript(surfPath)), 0o755); err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(NewManifest(wrapperPath, extensionIDs), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	data = append(data, '\n')
+
+	var written []string
+	for _, dir := range manifestDirs {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return written, err
+		}
+		path := filepath.Join(dir, HostName+".json")
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
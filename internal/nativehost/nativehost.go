@@ -0,0 +1,212 @@
+package nativehost
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+
+	"github.com/apermo/apermo-surf/internal/config"
+	"github.com/apermo/apermo-surf/internal/export"
+	"github.com/apermo/apermo-surf/internal/fuzzy"
+	"github.com/apermo/apermo-surf/internal/resolve"
+)
+
+// HostName is the native messaging host name the extension connects to.
+const HostName = "com.apermo.surf"
+
+// maxMessageSize is Chrome's limit for messages sent to the extension (1 MB).
+// Incoming messages are held to the same limit; surf requests are tiny.
+const maxMessageSize = 1024 * 1024
+
+// Request is a message sent by the extension.
+//
+//	{"type": "config", "dir": "/path/to/project"}
+//	{"type": "resolve", "dir": "/path/to/project", "name": "jira", "arg": "123"}
+//	{"type": "resolve", "dir": "/path/to/project", "name": "sentry", "env": "staging"}
+//	{"type": "resolve", "dir": "/path/to/project", "name": "orders", "params": ["4711"]}
+//	{"type": "resolve", "dir": "/path/to/project", "name": "docs search", "arg": "cache invalidation"}
+type Request struct {
+	Type   string   `json:"type"`
+	Dir    string   `json:"dir"`
+	Name   string   `json:"name,omitempty"`
+	Env    string   `json:"env,omitempty"`
+	Params []string `json:"params,omitempty"`
+	Arg    string   `json:"arg,omitempty"`
+}
+
+// Response is the reply to a single Request.
+type Response struct {
+	OK         bool           `json:"ok"`
+	Error      string         `json:"error,omitempty"`
+	Path       string         `json:"path,omitempty"`
+	Config     *export.Export `json:"config,omitempty"`
+	Name       string         `json:"name,omitempty"`
+	URL        string         `json:"url,omitempty"`
+	Warnings   []string       `json:"warnings,omitempty"`
+	Candidates []string       `json:"candidates,omitempty"`
+}
+
+// ReadMessage reads one length-prefixed message (native byte order uint32
+// length followed by JSON). Returns io.EOF when the input is closed cleanly.
+func ReadMessage(r io.Reader) ([]byte, error) {
+	var size uint32
+	if err := binary.Read(r, binary.NativeEndian, &size); err != nil {
+		return nil, err
+	}
+	if size > maxMessageSize {
+		return nil, fmt.Errorf("message too large: %d bytes", size)
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// WriteMessage encodes v as JSON and writes it with a length prefix.
+func WriteMessage(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if len(data) > maxMessageSize {
+		return fmt.Errorf("message too large: %d bytes", len(data))
+	}
+	if err := binary.Write(w, binary.NativeEndian, uint32(len(data))); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// Serve answers requests from r on w until r is closed.
+// Malformed requests get an error response; only I/O errors stop the loop.
+func Serve(r io.Reader, w io.Writer) error {
+	for {
+		msg, err := ReadMessage(r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req Request
+		var resp Response
+		if err := json.Unmarshal(msg, &req); err != nil {
+			resp = errorResponse(fmt.Errorf("invalid request: %w", err))
+		} else {
+			resp = Handle(req)
+		}
+
+		if err := WriteMessage(w, resp); err != nil {
+			return err
+		}
+	}
+}
+
+// Handle dispatches a single request.
+func Handle(req Request) Response {
+	switch req.Type {
+	case "config":
+		return handleConfig(req)
+	case "resolve":
+		return handleResolve(req)
+	default:
+		return errorResponse(fmt.Errorf("unknown request type %q", req.Type))
+	}
+}
+
+func handleConfig(req Request) Response {
+	path, cfg, err := load(req.Dir)
+	if err != nil {
+		return errorResponse(err)
+	}
+	dir := filepath.Dir(path)
+	e := export.Build(resolve.ForBranch(cfg, dir, false), dir)
+	return Response{OK: true, Path: path, Config: &e}
+}
+
+func handleResolve(req Request) Response {
+	if req.Name == "" {
+		return errorResponse(fmt.Errorf("resolve requires a name"))
+	}
+
+	path, cfg, err := load(req.Dir)
+	if err != nil {
+		return errorResponse(err)
+	}
+	cfg = resolve.ForBranch(cfg, filepath.Dir(path), false)
+
+	allLinks := cfg.AllLinks()
+	names := make([]string, 0, len(allLinks))
+	for name := range allLinks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if short, qualified := cfg.Ambiguous([]string{req.Name}); qualified != nil {
+		resp := errorResponse(fmt.Errorf("%q is ambiguous", short))
+		resp.Candidates = qualified
+		return resp
+	}
+	match, candidates := fuzzy.BestMatch(req.Name, names)
+	if match == "" {
+		resp := errorResponse(fmt.Errorf("no unique link matching %q", req.Name))
+		resp.Candidates = candidates
+		return resp
+	}
+
+	link := allLinks[match]
+	args := append([]string{}, req.Params...)
+	if req.Arg != "" {
+		args = append(args, req.Arg)
+	}
+	opts, err := resolve.ArgOptions(cfg, link, filepath.Dir(path), args)
+	if err != nil {
+		return errorResponse(err)
+	}
+	// The environment is a field of its own, so it is never taken for a
+	// param; links without {env.*} placeholders ignore it.
+	if req.Env != "" && resolve.UsesEnv(link) {
+		env, ok := cfg.Environments[req.Env]
+		if !ok {
+			return errorResponse(fmt.Errorf("unknown environment %q", req.Env))
+		}
+		opts.EnvName, opts.Env = req.Env, env
+	}
+	result, err := resolve.ResolveWith(link, opts)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return Response{
+		OK:       true,
+		Path:     path,
+		Name:     match,
+		URL:      result.URL,
+		Warnings: result.Warnings,
+	}
+}
+
+func load(dir string) (string, *config.Config, error) {
+	if dir == "" {
+		return "", nil, fmt.Errorf("request requires a dir")
+	}
+	path, err := config.Find(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return "", nil, err
+	}
+	return path, cfg, nil
+}
+
+func errorResponse(err error) Response {
+	return Response{Error: err.Error()}
+}
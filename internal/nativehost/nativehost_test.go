@@ -0,0 +1,237 @@
+package nativehost
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/apermo/apermo-surf/internal/config"
+)
+
+func writeConfig(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	data := `
+environments:
+  prod: https://example.com
+tools:
+  jira:
+    url: https://jira.example.com/browse/{ticket}
+    pattern: "PROJ-\\d+"
+`
+	if err := os.WriteFile(filepath.Join(dir, config.FileName), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// roundTrip sends requests through Serve using in-memory pipes.
+func roundTrip(t *testing.T, reqs ...any) []Response {
+	t.Helper()
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- Serve(inR, outW)
+		outW.Close()
+	}()
+
+	var resps []Response
+	for _, req := range reqs {
+		if err := WriteMessage(inW, req); err != nil {
+			t.Fatal(err)
+		}
+		msg, err := ReadMessage(outR)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var resp Response
+		if err := json.Unmarshal(msg, &resp); err != nil {
+			t.Fatal(err)
+		}
+		resps = append(resps, resp)
+	}
+
+	inW.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("Serve returned %v", err)
+	}
+	return resps
+}
+
+func TestMessage_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMessage(&buf, Request{Type: "config", Dir: "/x"}); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := ReadMessage(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(msg) != `{"type":"config","dir":"/x"}` {
+		t.Errorf("got %s", msg)
+	}
+}
+
+func TestReadMessage_TooLarge(t *testing.T) {
+	buf := bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff})
+	if _, err := ReadMessage(buf); err == nil {
+		t.Error("expected error for oversized message")
+	}
+}
+
+func TestServe_Config(t *testing.T) {
+	dir := writeConfig(t)
+	resps := roundTrip(t, Request{Type: "config", Dir: dir})
+
+	resp := resps[0]
+	if !resp.OK {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+	if resp.Path != filepath.Join(dir, config.FileName) {
+		t.Errorf("path = %q", resp.Path)
+	}
+	if resp.Config == nil || len(resp.Config.Links) != 2 {
+		t.Fatalf("config = %+v", resp.Config)
+	}
+}
+
+func TestServe_Resolve(t *testing.T) {
+	dir := writeConfig(t)
+	resps := roundTrip(t,
+		Request{Type: "resolve", Dir: dir, Name: "jira", Arg: "123"},
+		Request{Type: "resolve", Dir: dir, Name: "nothing-like-this"},
+	)
+
+	if !resps[0].OK {
+		t.Fatalf("unexpected error: %s", resps[0].Error)
+	}
+	if resps[0].URL != "https://jira.example.com/browse/PROJ-123" {
+		t.Errorf("url = %q", resps[0].URL)
+	}
+
+	if resps[1].OK || resps[1].Error == "" {
+		t.Errorf("expected error response, got %+v", resps[1])
+	}
+}
+
+func TestServe_ResolveEnv(t *testing.T) {
+	dir := t.TempDir()
+	data := `
+environments:
+  prod: https://example.com
+  staging: https://staging.example.com
+tools:
+  jira:
+    url: https://jira.example.com/browse/{ticket}
+    pattern: "PROJ-\\d+"
+  sentry: https://sentry.io/{env.name}
+`
+	if err := os.WriteFile(filepath.Join(dir, config.FileName), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resps := roundTrip(t,
+		Request{Type: "resolve", Dir: dir, Name: "jira", Env: "staging", Arg: "123"},
+		Request{Type: "resolve", Dir: dir, Name: "sentry", Env: "staging"},
+		Request{Type: "resolve", Dir: dir, Name: "sentry", Env: "nope"},
+	)
+
+	if !resps[0].OK || resps[0].URL != "https://jira.example.com/browse/PROJ-123" {
+		t.Errorf("link without env placeholders: %+v", resps[0])
+	}
+	if !resps[1].OK || resps[1].URL != "https://sentry.io/staging" {
+		t.Errorf("link with env placeholders: %+v", resps[1])
+	}
+	if resps[2].OK {
+		t.Errorf("expected error for unknown environment, got %+v", resps[2])
+	}
+}
+
+func TestServe_MalformedRequestKeepsRunning(t *testing.T) {
+	dir := writeConfig(t)
+	resps := roundTrip(t,
+		json.RawMessage(`"not an object"`),
+		Request{Type: "bogus"},
+		Request{Type: "config", Dir: dir},
+	)
+
+	if resps[0].OK || !strings.Contains(resps[0].Error, "invalid request") {
+		t.Errorf("resp[0] = %+v", resps[0])
+	}
+	if resps[1].OK || !strings.Contains(resps[1].Error, "unknown request type") {
+		t.Errorf("resp[1] = %+v", resps[1])
+	}
+	if !resps[2].OK {
+		t.Errorf("resp[2] = %+v", resps[2])
+	}
+}
+
+func TestInstall(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("install is linux only")
+	}
+	home := t.TempDir()
+	wrapper := filepath.Join(home, "surf", "native-host.sh")
+
+	written, err := Install("/usr/local/bin/surf", wrapper, []string{"abcdef"}, ManifestDirs(home))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 2 {
+		t.Fatalf("wrote %d manifests, want 2", len(written))
+	}
+
+	data, err := os.ReadFile(filepath.Join(home, "chromium", "NativeMessagingHosts", HostName+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Path != wrapper {
+		t.Errorf("path = %q, want %q", m.Path, wrapper)
+	}
+	if len(m.AllowedOrigins) != 1 || m.AllowedOrigins[0] != "chrome-extension://abcdef/" {
+		t.Errorf("allowed_origins = %v", m.AllowedOrigins)
+	}
+
+	script, err := os.ReadFile(wrapper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(script), `'/usr/local/bin/surf' native-host`) {
+		t.Errorf("wrapper script = %q", script)
+	}
+}
+
+func TestWrapperScript_Quoting(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+	dir := filepath.Join(t.TempDir(), `it's $HOME "and" `+"`x`")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	surf := filepath.Join(dir, "surf")
+	if err := os.WriteFile(surf, []byte("#!/bin/sh\necho \"$@\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := exec.Command(sh, "-c", WrapperScript(surf), "sh", "chrome-extension://abcdef/").CombinedOutput()
+	if err != nil {
+		t.Fatalf("wrapper failed: %v\n%s", err, out)
+	}
+	if got := strings.TrimSpace(string(out)); got != "native-host chrome-extension://abcdef/" {
+		t.Errorf("output = %q", got)
+	}
+}
@@ -89,3 +89,25 @@ func pickWithList(names []string, urls []string, in io.Reader, out io.Writer) (i
 
 	return choice - 1, nil
 }
+
+// IsTerminal reports whether stdin is an interactive terminal.
+func IsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Prompt asks for a single value on the terminal.
+func Prompt(label string) (string, error) {
+	return prompt(label, os.Stdin, os.Stderr)
+}
+
+// prompt writes label to out and reads one line from in.
+// Accepts io.Reader/Writer for testability.
+func prompt(label string, in io.Reader, out io.Writer) (string, error) {
+	fmt.Fprintf(out, "%s: ", label)
+	scanner := bufio.NewScanner(in)
+	if !scanner.Scan() {
+		return "", fmt.Errorf("prompt cancelled")
+	}
+	return strings.TrimSpace(scanner.Text()), nil
+}
@@ -73,3 +73,23 @@ func TestPickWithList_Output(t *testing.T) {
 		t.Error("output should contain prompt")
 	}
 }
+
+func TestPrompt(t *testing.T) {
+	var out bytes.Buffer
+	value, err := prompt("Order ID", strings.NewReader(" 4711 \n"), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "4711" {
+		t.Errorf("got %q, want 4711", value)
+	}
+	if out.String() != "Order ID: " {
+		t.Errorf("prompt = %q", out.String())
+	}
+}
+
+func TestPrompt_Cancelled(t *testing.T) {
+	if _, err := prompt("Order ID", strings.NewReader(""), &bytes.Buffer{}); err == nil {
+		t.Error("expected error on EOF")
+	}
+}
@@ -0,0 +1,12 @@
+package placeholder
+
+import "fmt"
+
+func ExampleExpand() {
+	values := map[string]string{"branch": "feature/PROJ-123"}
+	url, _ := Expand("https://{branch|slug}.preview.example.com/{ticket|default:BOARD}", func(name string) string {
+		return values[name]
+	})
+	fmt.Println(url)
+	// Output: https://feature-proj-123.preview.example.com/BOARD
+}
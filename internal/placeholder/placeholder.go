@@ -0,0 +1,187 @@
+package placeholder
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Placeholder is a single {name|filter|filter:arg} token in a URL.
+type Placeholder struct {
+	// Raw is the token as written, including braces.
+	Raw     string
+	Name    string
+	Filters []Filter
+}
+
+// Filter is one step of a placeholder's filter chain, e.g. slug or
+// default:BOARD.
+type Filter struct {
+	Name string
+	Arg  string
+}
+
+// token matches a placeholder: a name made of letters, digits, and _ . : -
+// followed by any number of |filter parts.
+var token = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_.:-]*)((?:\|[^{}|]*)*)\}`)
+
+// filters maps filter names to their implementations. The default filter
+// is handled separately since it is the only one applied to empty values.
+var filters = map[string]func(string) string{
+	"lower":     strings.ToLower,
+	"upper":     strings.ToUpper,
+	"slug":      Slug,
+	"urlencode": urlEncode,
+	"raw":       func(s string) string { return s },
+}
+
+// Parse returns the placeholders in s in order of appearance.
+func Parse(s string) []Placeholder {
+	var out []Placeholder
+	for _, m := range token.FindAllStringSubmatch(s, -1) {
+		out = append(out, newPlaceholder(m[0], m[1], m[2]))
+	}
+	return out
+}
+
+// newPlaceholder builds a Placeholder from the parts matched by token.
+func newPlaceholder(raw, name, filters string) Placeholder {
+	p := Placeholder{Raw: raw, Name: name}
+	if filters != "" {
+		for _, part := range strings.Split(filters[1:], "|") {
+			name, arg, _ := strings.Cut(part, ":")
+			p.Filters = append(p.Filters, Filter{Name: strings.TrimSpace(name), Arg: arg})
+		}
+	}
+	return p
+}
+
+// Validate reports the first unknown filter used in s.
+func Validate(s string) error {
+	for _, p := range Parse(s) {
+		for _, f := range p.Filters {
+			if f.Name == "default" {
+				continue
+			}
+			if _, ok := filters[f.Name]; !ok {
+				return fmt.Errorf("unknown filter %q in %s", f.Name, p.Raw)
+			}
+		}
+	}
+	return nil
+}
+
+// Apply runs the placeholder's filters over value, left to right.
+// default:X replaces an empty value with X; every other filter is skipped
+// while the value is empty. Unknown filters are ignored (see Validate).
+func (p Placeholder) Apply(value string) string {
+	for _, f := range p.Filters {
+		if f.Name == "default" {
+			if value == "" {
+				value = f.Arg
+			}
+			continue
+		}
+		if fn, ok := filters[f.Name]; ok && value != "" {
+			value = fn(value)
+		}
+	}
+	return value
+}
+
+// Expand replaces every placeholder in s with its filtered value from
+// lookup. Placeholders that end up empty are left in place and returned as
+// missing, so callers can warn about them and strip them.
+func Expand(s string, lookup func(name string) string) (string, []Placeholder) {
+	return ExpandURL(s, lookup, nil)
+}
+
+// ExpandURL is Expand for URLs: the values of placeholders for which escape
+// reports true are escaped for where they appear. Values in the path are
+// escaped per segment, keeping "/"; values in the query or fragment are
+// query-escaped. A value whose last filter is urlencode is already encoded,
+// and one filtered with raw is inserted as is.
+func ExpandURL(s string, lookup func(name string) string, escape func(name string) bool) (string, []Placeholder) {
+	var b strings.Builder
+	var missing []Placeholder
+	seen := make(map[string]bool)
+	inPath := true
+	last := 0
+	for _, m := range token.FindAllStringSubmatchIndex(s, -1) {
+		literal := s[last:m[0]]
+		if strings.ContainsAny(literal, "?#") {
+			inPath = false
+		}
+		b.WriteString(literal)
+		last = m[1]
+
+		p := newPlaceholder(s[m[0]:m[1]], s[m[2]:m[3]], s[m[4]:m[5]])
+		value := p.Apply(lookup(p.Name))
+		if value == "" {
+			if !seen[p.Raw] {
+				seen[p.Raw] = true
+				missing = append(missing, p)
+			}
+			b.WriteString(p.Raw)
+			continue
+		}
+		if escape != nil && escape(p.Name) && !p.Encoded() {
+			if inPath {
+				value = pathEscape(value)
+			} else {
+				value = url.QueryEscape(value)
+			}
+		}
+		b.WriteString(value)
+	}
+	b.WriteString(s[last:])
+	return b.String(), missing
+}
+
+// Encoded reports whether the placeholder's value is already encoded by its
+// filters, or marked raw.
+func (p Placeholder) Encoded() bool {
+	for _, f := range p.Filters {
+		if f.Name == "raw" {
+			return true
+		}
+	}
+	return len(p.Filters) > 0 && p.Filters[len(p.Filters)-1].Name == "urlencode"
+}
+
+// pathEscape escapes each "/"-separated segment of s for a URL path.
+func pathEscape(s string) string {
+	segments := strings.Split(s, "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+// Slug lowercases s and collapses every run of characters other than
+// ASCII letters and digits into a single hyphen, e.g. "feature/PROJ-1 Foo"
+// becomes "feature-proj-1-foo". The result is safe as a DNS label or path
+// segment.
+func Slug(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		if !hyphen && b.Len() > 0 {
+			b.WriteByte('-')
+			hyphen = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// urlEncode escapes s for use in a path segment or query value. Spaces
+// become %20 rather than +, which is only valid in queries.
+func urlEncode(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
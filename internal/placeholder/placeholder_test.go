@@ -0,0 +1,107 @@
+package placeholder
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	ps := Parse("https://{branch|slug}.example.com/{ticket|default:BOARD}/{repo}")
+	if len(ps) != 3 {
+		t.Fatalf("got %d placeholders, want 3", len(ps))
+	}
+	if ps[0].Name != "branch" || len(ps[0].Filters) != 1 || ps[0].Filters[0].Name != "slug" {
+		t.Errorf("branch = %+v", ps[0])
+	}
+	if f := ps[1].Filters[0]; f.Name != "default" || f.Arg != "BOARD" {
+		t.Errorf("ticket filter = %+v", f)
+	}
+	if ps[2].Raw != "{repo}" || ps[2].Filters != nil {
+		t.Errorf("repo = %+v", ps[2])
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		token string
+		value string
+		want  string
+	}{
+		{"{branch|slug}", "feature/PROJ-12 Fix Login", "feature-proj-12-fix-login"},
+		{"{branch|lower|urlencode}", "Fix/#12 now", "fix%2F%2312%20now"},
+		{"{repo|upper}", "shop", "SHOP"},
+		{"{ticket|default:BOARD}", "", "BOARD"},
+		{"{ticket|default:BOARD}", "PROJ-1", "PROJ-1"},
+		{"{ticket|upper|default:none}", "", "none"},
+		{"{ticket|default:none|upper}", "", "NONE"},
+		{"{branch|slug}", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.token+"/"+tt.value, func(t *testing.T) {
+			ps := Parse(tt.token)
+			if len(ps) != 1 {
+				t.Fatalf("Parse(%q) = %v", tt.token, ps)
+			}
+			if got := ps[0].Apply(tt.value); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate("https://{branch|slug|lower}.example.com/{ticket|default:x}"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := Validate("https://example.com/{branch|kebab}"); err == nil {
+		t.Error("expected error for unknown filter")
+	}
+}
+
+func TestExpand(t *testing.T) {
+	values := map[string]string{"branch": "Feature/X", "repo": "shop"}
+	got, missing := Expand("https://{branch|slug}.example.com/{repo}/{ticket}", func(name string) string {
+		return values[name]
+	})
+	if got != "https://feature-x.example.com/shop/{ticket}" {
+		t.Errorf("got %q", got)
+	}
+	if len(missing) != 1 || missing[0].Raw != "{ticket}" {
+		t.Errorf("missing = %+v", missing)
+	}
+}
+
+func TestExpandURL(t *testing.T) {
+	values := map[string]string{"q": "a b/c?d", "repo": "my shop"}
+	lookup := func(name string) string { return values[name] }
+	escape := func(name string) bool { return name == "q" }
+
+	tests := map[string]string{
+		"https://example.com/{repo}/{q}":        "https://example.com/my shop/a%20b/c%3Fd",
+		"https://example.com/s?q={q}":           "https://example.com/s?q=a+b%2Fc%3Fd",
+		"https://example.com/s?q={q|urlencode}": "https://example.com/s?q=a%20b%2Fc%3Fd",
+		"https://example.com/s?q={q}#{q|upper}": "https://example.com/s?q=a+b%2Fc%3Fd#A+B%2FC%3FD",
+	}
+	for in, want := range tests {
+		if got, _ := ExpandURL(in, lookup, escape); got != want {
+			t.Errorf("ExpandURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSlug(t *testing.T) {
+	tests := map[string]string{
+		"main":                  "main",
+		"feature/PROJ-123":      "feature-proj-123",
+		"--weird__name--":       "weird-name",
+		"Ümlaut branch":         "mlaut-branch",
+		"release/1.2.0":         "release-1-2-0",
+		"":                      "",
+		"fix #42: broken  link": "fix-42-broken-link",
+	}
+	for in, want := range tests {
+		if got := Slug(in); got != want {
+			t.Errorf("Slug(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -7,19 +7,27 @@ import (
 	"github.com/apermo/apermo-surf/internal/config"
 )
 
-func ExampleResolve_simple() {
+func ExampleResolveWith_simple() {
 	link := config.Link{URL: "https://example.com"}
-	result := Resolve(link, os.TempDir(), "")
+	result, err := ResolveWith(link, Options{Dir: os.TempDir()})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	fmt.Println(result.URL)
 	// Output: https://example.com
 }
 
-func ExampleResolve_withTicket() {
+func ExampleResolveWith_withTicket() {
 	link := config.Link{
 		URL:     "https://jira.example.com/browse/{ticket}",
 		Pattern: `PROJ-\d+`,
 	}
-	result := Resolve(link, os.TempDir(), "PROJ-456")
+	result, err := ResolveWith(link, Options{Dir: os.TempDir(), Ticket: "PROJ-456"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	fmt.Println(result.URL)
 	// Output: https://jira.example.com/browse/PROJ-456
 }
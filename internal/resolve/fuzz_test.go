@@ -16,7 +16,7 @@ func FuzzExtractLiteralPrefix(f *testing.F) {
 	})
 }
 
-func FuzzStripPlaceholderSegment(f *testing.F) {
+func FuzzStripPlaceholders(f *testing.F) {
 	f.Add("https://example.com/browse/{ticket}")
 	f.Add("https://example.com")
 	f.Add("https://example.com/{a}/{b}")
@@ -24,8 +24,9 @@ func FuzzStripPlaceholderSegment(f *testing.F) {
 	f.Add("not-a-url")
 	f.Add("https://example.com/path/{ticket}/sub")
 	f.Add("://broken")
+	f.Add("https://{a}.example.com/p?q={b}&r=1#{c}")
 	f.Fuzz(func(t *testing.T, rawURL string) {
-		stripPlaceholderSegment(rawURL)
+		_, _ = stripPlaceholders(rawURL)
 	})
 }
 
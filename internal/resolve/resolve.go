@@ -1,12 +1,19 @@
 package resolve
 
 import (
+	"context"
 	"fmt"
 	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/apermo/apermo-surf/internal/config"
 	"github.com/apermo/apermo-surf/internal/git"
+	"github.com/apermo/apermo-surf/internal/placeholder"
+	"github.com/apermo/apermo-surf/internal/trust"
 )
 
 // Result holds a resolved URL and any warnings generated during resolution.
@@ -15,54 +22,492 @@ type Result struct {
 	Warnings []string
 }
 
-// Resolve replaces placeholders in a link's URL with git-derived values.
-// configDir is the directory containing .surf-links.yml (used as git context).
-// explicitArg overrides {ticket} when non-empty (resolution: explicit → branch → fallback).
-func Resolve(link config.Link, configDir string, explicitArg string) Result {
-	rawURL := link.URL
+// Options is the context a link is resolved in.
+type Options struct {
+	// Dir is the git context directory (the directory containing
+	// .surf-links.yml). Links that know their source file use that
+	// file's directory instead.
+	Dir string
+	// Ticket overrides {ticket} when non-empty
+	// (resolution: explicit → branch → fallback).
+	Ticket string
+	// Params are positional values for the link's named params, in the
+	// order the params appear in the URL.
+	Params []string
+	// Query is the free text for {query}; it is escaped for its position
+	// in the URL on use.
+	Query string
+	// DeepLink is a path ("/wp-admin"), query ("?page=2"), or fragment
+	// ("#top") joined onto the resolved URL.
+	DeepLink string
+	// Prompt, when set, asks for params that have no positional value.
+	Prompt func(label string) (string, error)
+	// EnvName and Env select the environment for {env.*} placeholders.
+	EnvName string
+	Env     config.Link
+	// Vars are the config's top-level variables for {vars.*} placeholders.
+	Vars map[string]config.Var
+	// Trusted reports whether a config file may run commands and read
+	// process environment variables. Command vars and {env:VAR} from
+	// untrusted files resolve to nothing; a nil Trusted trusts no file.
+	// Vars and links without a source file (the user config) are always
+	// trusted.
+	Trusted func(path string) bool
+}
+
+// commandTimeout bounds how long a command var may run.
+const commandTimeout = 5 * time.Second
+
+// ResolveWith replaces placeholders in a link's URL using opts.
+// {env:VAR} reads a process environment variable and {vars.<name>} a
+// top-level config variable, running its command with sh -c; both need a
+// trusted config file.
+// Git placeholders ({branch}, {repo}, {ticket}, {commit}, {short_commit},
+// {tag}, {upstream}, {owner}, {host}, {default_branch}) are only looked up
+// when the URL uses them; {env.name}, {env.url}, and {env.<var>} come from
+// the selected environment. Placeholder filters such as {branch|slug} or
+// {ticket|default:BOARD} are applied to each value.
+//
+// Named params are taken from opts.Params or asked for with opts.Prompt.
+// opts.DeepLink is joined onto the resolved URL. It returns an error when
+// a param value does not match its pattern, prompting fails, or the link's
+// on_missing policy is error.
+func ResolveWith(link config.Link, opts Options) (Result, error) {
+	result, err := resolveURL(link, opts)
+	if err != nil || opts.DeepLink == "" {
+		return result, err
+	}
+	result.URL = config.JoinURL(result.URL, opts.DeepLink)
+	return result, nil
+}
+
+// resolveURL resolves the link's own URL; see ResolveWith.
+func resolveURL(link config.Link, opts Options) (Result, error) {
+	rawURL := pathQuery(config.AppendQuery(link.URL, link.Query, queryTemplate))
 
 	if !strings.Contains(rawURL, "{") {
-		return Result{URL: rawURL}
+		return Result{URL: rawURL}, nil
+	}
+
+	params, err := paramValues(link, opts)
+	if err != nil {
+		return Result{}, err
+	}
+
+	configDir := opts.Dir
+	if dir := link.Dir(); dir != "" {
+		configDir = dir
 	}
 
 	var warnings []string
 
-	branch, _ := git.Branch(configDir)
-	repo, _ := git.Repo(configDir)
+	// Each git value is looked up on first use only.
+	branch := lazy(func() string { b, _ := git.Branch(configDir); return b })
+	remote := lazy(func() git.Remote { r, _ := git.OriginRemote(configDir); return r })
+	values := map[string]func() string{
+		"branch": branch,
+		"repo":   func() string { return remote().Name },
+		"owner":  func() string { return remote().Owner },
+		"host":   func() string { return remote().Host },
+		"ticket": func() string {
+			// Ticket resolution: explicit arg → branch extraction → empty
+			if opts.Ticket != "" {
+				return resolveExplicitArg(opts.Ticket, link.Pattern)
+			}
+			t, _ := git.Ticket(branch(), link.Pattern)
+			return t
+		},
+		"commit":         gitValue(git.Commit, configDir),
+		"short_commit":   gitValue(git.ShortCommit, configDir),
+		"tag":            gitValue(git.Tag, configDir),
+		"upstream":       gitValue(git.Upstream, configDir),
+		"default_branch": gitValue(git.DefaultBranch, configDir),
+	}
+	lookup := func(name string) string {
+		if value, ok := params[name]; ok {
+			return value
+		}
+		if name == config.QueryPlaceholder {
+			return opts.Query
+		}
+		if key, ok := strings.CutPrefix(name, "env."); ok {
+			return envValue(opts, key)
+		}
+		if key, ok := strings.CutPrefix(name, "env:"); ok {
+			if link.Source != "" && (opts.Trusted == nil || !opts.Trusted(link.Source)) {
+				warnings = append(warnings, fmt.Sprintf("env:%s: %s is not trusted to read environment variables — run surf trust", key, link.Source))
+				return ""
+			}
+			return os.Getenv(key)
+		}
+		if key, ok := strings.CutPrefix(name, config.VarPrefix); ok {
+			value, warning := varValue(opts, key, configDir)
+			if warning != "" {
+				warnings = append(warnings, warning)
+			}
+			return value
+		}
+		if value, ok := values[name]; ok {
+			return value()
+		}
+		return ""
+	}
+
+	rawURL, missing := placeholder.ExpandURL(rawURL, lookup, escaped)
+	if len(missing) == 0 {
+		return Result{URL: rawURL, Warnings: warnings}, nil
+	}
 
-	// Ticket resolution: explicit arg → branch extraction → empty
-	var ticket string
-	if explicitArg != "" {
-		ticket = resolveExplicitArg(explicitArg, link.Pattern)
-	} else {
-		ticket, _ = git.Ticket(branch, link.Pattern)
+	fallback := func() (Result, error) {
+		fallbackURL, _ := placeholder.ExpandURL(link.FallbackURL, lookup, escaped)
+		warnings = append(warnings, fmt.Sprintf("could not resolve %s, opening fallback_url", rawNames(missing)))
+		stripped, err := stripPlaceholders(fallbackURL)
+		if err != nil {
+			return Result{}, fmt.Errorf("fallback_url: %w", err)
+		}
+		return Result{URL: stripped, Warnings: warnings}, nil
 	}
 
-	replacements := map[string]string{
-		"{branch}": branch,
-		"{repo}":   repo,
-		"{ticket}": ticket,
+	switch link.OnMissing {
+	case config.OnMissingError:
+		return Result{}, fmt.Errorf("could not resolve %s", rawNames(missing))
+	case config.OnMissingFallback:
+		return fallback()
+	case config.OnMissingPrompt:
+		if opts.Prompt != nil {
+			var err error
+			rawURL, missing, err = promptMissing(rawURL, missing, opts.Prompt, escaped)
+			if err != nil {
+				return Result{}, err
+			}
+		}
 	}
 
-	for placeholder, value := range replacements {
-		if !strings.Contains(rawURL, placeholder) {
-			continue
+	stripped, err := stripPlaceholders(rawURL)
+	if err != nil {
+		// Dropping part of the host would open a different site
+		if link.FallbackURL != "" {
+			return fallback()
+		}
+		return Result{}, fmt.Errorf("could not resolve %s: %w", rawNames(missing), err)
+	}
+	for _, p := range missing {
+		warnings = append(warnings, fmt.Sprintf("could not resolve %s", p.Raw))
+	}
+	return Result{URL: stripped, Warnings: warnings}, nil
+}
+
+// queryTemplate URL-encodes the literal text of a query value and adds a
+// urlencode filter to its placeholders, so both end up encoded once
+// resolved.
+func queryTemplate(value string) string {
+	var b strings.Builder
+	rest := value
+	for _, p := range placeholder.Parse(value) {
+		i := strings.Index(rest, p.Raw)
+		b.WriteString(queryEscape(rest[:i]))
+		rest = rest[i+len(p.Raw):]
+		b.WriteString(urlencoded(p))
+	}
+	b.WriteString(queryEscape(rest))
+	return b.String()
+}
+
+// pathQuery adds a urlencode filter to {query} placeholders in the path of
+// rawURL, so search text is escaped as a single segment, "/" included.
+func pathQuery(rawURL string) string {
+	var b strings.Builder
+	rest := rawURL
+	for _, p := range placeholder.Parse(rawURL) {
+		i := strings.Index(rest, p.Raw)
+		literal := rest[:i]
+		if strings.ContainsAny(literal, "?#") {
+			break
 		}
-		if value == "" {
-			warnings = append(warnings, fmt.Sprintf("could not resolve %s", placeholder))
+		b.WriteString(literal)
+		rest = rest[i+len(p.Raw):]
+		if p.Name == config.QueryPlaceholder {
+			b.WriteString(urlencoded(p))
 		} else {
-			rawURL = strings.ReplaceAll(rawURL, placeholder, value)
+			b.WriteString(p.Raw)
 		}
 	}
+	b.WriteString(rest)
+	return b.String()
+}
 
-	rawURL = stripPlaceholderSegment(rawURL)
+// urlencoded returns the placeholder as written with a urlencode filter
+// added, unless its value is already encoded or marked raw.
+func urlencoded(p placeholder.Placeholder) string {
+	if p.Encoded() {
+		return p.Raw
+	}
+	return strings.TrimSuffix(p.Raw, "}") + "|urlencode}"
+}
+
+// queryEscape escapes s like the urlencode filter, with spaces as %20.
+func queryEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
 
-	return Result{URL: rawURL, Warnings: warnings}
+// promptMissing asks for each missing placeholder and substitutes the
+// filtered answer. Placeholders still empty afterwards are returned.
+func promptMissing(rawURL string, missing []placeholder.Placeholder, prompt func(string) (string, error), escaped func(string) bool) (string, []placeholder.Placeholder, error) {
+	answers := make(map[string]string)
+	for _, p := range missing {
+		if _, asked := answers[p.Name]; asked {
+			continue
+		}
+		value, err := prompt(p.Name)
+		if err != nil {
+			return "", nil, err
+		}
+		answers[p.Name] = value
+	}
+	rawURL, still := placeholder.ExpandURL(rawURL, func(name string) string { return answers[name] }, escaped)
+	return rawURL, still, nil
+}
+
+// rawNames lists placeholders as written, e.g. "{ticket}, {branch|slug}".
+func rawNames(ps []placeholder.Placeholder) string {
+	raw := make([]string, len(ps))
+	for i, p := range ps {
+		raw[i] = p.Raw
+	}
+	return strings.Join(raw, ", ")
+}
+
+// paramValues collects the link's named params from the positional values
+// in opts, prompting for the rest when opts.Prompt is set. A param left
+// without a value maps to "".
+func paramValues(link config.Link, opts Options) (map[string]string, error) {
+	values := make(map[string]string)
+	for i, name := range link.ParamNames() {
+		p := link.Params[name]
+
+		var value string
+		switch {
+		case i < len(opts.Params):
+			value = opts.Params[i]
+		case opts.Prompt != nil:
+			label := p.Prompt
+			if label == "" {
+				label = name
+			}
+			v, err := opts.Prompt(label)
+			if err != nil {
+				return nil, err
+			}
+			value = v
+		}
+
+		if value != "" && !p.Match(value) {
+			return nil, fmt.Errorf("%s %q does not match %s", name, value, p.Pattern)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// escaped reports whether the value of a placeholder is escaped for its
+// position in the URL. Params, search terms, git values and vars may contain
+// "#", "?" or spaces (e.g. a branch "feature/#12 fix"), so every value is
+// escaped except {env.url}, which is a base URL. Use the raw filter to
+// insert a value as is.
+func escaped(name string) bool {
+	return name != "env.url"
+}
+
+// lazy returns a function that calls fn once and caches its result.
+func lazy[T any](fn func() T) func() T {
+	var value T
+	var done bool
+	return func() T {
+		if !done {
+			value, done = fn(), true
+		}
+		return value
+	}
+}
+
+// gitValue adapts a git lookup to a lazy placeholder value.
+func gitValue(fn func(dir string) (string, error), dir string) func() string {
+	return lazy(func() string { v, _ := fn(dir); return v })
+}
+
+// varValue returns the value of the top-level var name, running its
+// command if it has one and its source file is trusted. A non-empty
+// warning explains why a command did not produce a value.
+func varValue(opts Options, name, dir string) (string, string) {
+	v, ok := opts.Vars[name]
+	if !ok || v.Command == "" {
+		return v.Value, ""
+	}
+
+	if v.Source != "" {
+		if opts.Trusted == nil || !opts.Trusted(v.Source) {
+			return "", fmt.Sprintf("vars.%s: %s is not trusted to run commands — run surf trust", name, v.Source)
+		}
+		dir = filepath.Dir(v.Source)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", v.Command)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Sprintf("vars.%s: %s: %v", name, v.Command, err)
+	}
+	return strings.TrimSpace(string(out)), ""
+}
+
+// envValue looks up an {env.<key>} value. Environment vars take precedence
+// over the built-in name and url keys.
+func envValue(opts Options, key string) string {
+	if v, ok := opts.Env.Vars[key]; ok {
+		return v
+	}
+	switch key {
+	case "name":
+		return opts.EnvName
+	case "url":
+		return opts.Env.URL
+	}
+	return ""
+}
+
+// UsesEnv reports whether a link's URL has {env.*} placeholders.
+func UsesEnv(link config.Link) bool {
+	for _, p := range placeholder.Parse(link.FullURL()) {
+		if strings.HasPrefix(p.Name, "env.") {
+			return true
+		}
+	}
+	return false
+}
+
+// EnvArg selects the environment for a link with {env.*} placeholders.
+// If the first of args is an environment name, or a prefix of exactly one,
+// that environment is used and the remaining args are returned; otherwise
+// the default environment is used and args are returned unchanged. Returns
+// "" for links without {env.*} placeholders.
+func EnvArg(link config.Link, environments map[string]config.Link, args []string) (string, []string) {
+	if !UsesEnv(link) || len(environments) == 0 {
+		return "", args
+	}
+
+	if len(args) > 0 {
+		if name := envName(args[0], environments); name != "" {
+			return name, args[1:]
+		}
+	}
+	return config.DefaultEnvironment(environments), args
+}
+
+// envName returns the environment named arg, or the only one it is a
+// prefix of, ignoring case; "" when there is none or several.
+func envName(arg string, environments map[string]config.Link) string {
+	lower := strings.ToLower(arg)
+	var prefixed []string
+	for name := range environments {
+		if strings.ToLower(name) == lower {
+			return name
+		}
+		if strings.HasPrefix(strings.ToLower(name), lower) {
+			prefixed = append(prefixed, name)
+		}
+	}
+	if len(prefixed) != 1 {
+		return ""
+	}
+	return prefixed[0]
+}
+
+// ArgOptions builds resolve options for a link of cfg from the args
+// following its name: an environment name first for links with {env.*}
+// placeholders (see EnvArg), then one value per named param, then the
+// explicit ticket. For search links all remaining args are joined into the
+// query instead; otherwise any further args are an error, except for one
+// deep link (see DeepLinkArg). A "#123" argument is a ticket, not a
+// fragment, for links with a {ticket} placeholder. Command vars and
+// {env:VAR} work in files trusted with surf trust, as loaded into cfg.
+func ArgOptions(cfg *config.Config, link config.Link, dir string, args []string) (Options, error) {
+	var deepLink string
+	if !link.IsSearch() {
+		var err error
+		if deepLink, args, err = DeepLinkArg(args, !usesTicket(link)); err != nil {
+			return Options{}, err
+		}
+	}
+
+	envName, rest := EnvArg(link, cfg.Environments, args)
+	opts := Options{
+		Dir:      dir,
+		EnvName:  envName,
+		Env:      cfg.Environments[envName],
+		Vars:     cfg.Vars,
+		Trusted:  func(path string) bool { return trust.IsTrustedSum(path, cfg.Digests[path]) },
+		DeepLink: deepLink,
+	}
+	n := min(len(link.ParamNames()), len(rest))
+	opts.Params, rest = rest[:n], rest[n:]
+	if link.IsSearch() {
+		opts.Query = strings.Join(rest, " ")
+		return opts, nil
+	}
+	if len(rest) > 0 {
+		opts.Ticket, rest = rest[0], rest[1:]
+	}
+	if len(rest) > 0 {
+		return Options{}, fmt.Errorf("too many arguments: %s", strings.Join(rest, " "))
+	}
+	return opts, nil
+}
+
+// ForBranch returns cfg as seen on the git branch checked out in dir, with
+// the branch's pins added. With all set, branch conditions are not applied
+// and every link is kept.
+func ForBranch(cfg *config.Config, dir string, all bool) *config.Config {
+	branch, _ := git.Branch(dir)
+	if !all {
+		cfg = cfg.ForBranch(branch)
+	}
+	pins, _ := git.Pins(dir, branch)
+	if len(pins) == 0 {
+		return cfg
+	}
+	return cfg.WithPins(pins, git.ConfigPath(dir))
+}
+
+// DeepLinkArg takes the argument starting with "/" or "?" out of args, e.g.
+// "/wp-admin/plugins.php?plugin_status=active", and with fragments set also
+// one starting with "#". More than one such argument is an error.
+func DeepLinkArg(args []string, fragments bool) (string, []string, error) {
+	var deepLink string
+	var rest []string
+	for _, arg := range args {
+		isDeep := strings.HasPrefix(arg, "/") || strings.HasPrefix(arg, "?") ||
+			(fragments && strings.HasPrefix(arg, "#"))
+		if !isDeep {
+			rest = append(rest, arg)
+			continue
+		}
+		if deepLink != "" {
+			return "", nil, fmt.Errorf("more than one deep link: %s and %s", deepLink, arg)
+		}
+		deepLink = arg
+	}
+	return deepLink, rest, nil
 }
 
 // resolveExplicitArg applies auto-prefix logic to the explicit ticket argument.
 // If arg is a bare number and the pattern has a literal prefix, it prepends the prefix.
 func resolveExplicitArg(arg, pattern string) string {
+	// "#123" is the issue number 123
+	if n, ok := strings.CutPrefix(arg, "#"); ok && isDigits(n) {
+		arg = n
+	}
 	if pattern == "" {
 		return arg
 	}
@@ -78,12 +523,33 @@ func resolveExplicitArg(arg, pattern string) string {
 	}
 
 	// Bare number → auto-prefix
-	for _, r := range arg {
+	if !isDigits(arg) {
+		return arg
+	}
+	return prefix + arg
+}
+
+// isDigits reports whether s is a non-empty run of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
 		if r < '0' || r > '9' {
-			return arg
+			return false
 		}
 	}
-	return prefix + arg
+	return true
+}
+
+// usesTicket reports whether the link's URL has a {ticket} placeholder.
+func usesTicket(link config.Link) bool {
+	for _, p := range placeholder.Parse(link.FullURL()) {
+		if p.Name == "ticket" {
+			return true
+		}
+	}
+	return false
 }
 
 // extractLiteralPrefix returns the literal prefix of a regex pattern,
@@ -100,30 +566,62 @@ func extractLiteralPrefix(pattern string) string {
 	return prefix.String()
 }
 
-// stripPlaceholderSegment removes path segments containing unresolved {…} placeholders.
-func stripPlaceholderSegment(rawURL string) string {
-	u, err := url.Parse(rawURL)
-	if err != nil {
-		return rawURL
+// stripPlaceholders removes every path segment, query parameter, and
+// fragment holding an unresolved {…} placeholder. Parts are handled in
+// their escaped form so encoded values (e.g. from the urlencode filter)
+// survive unchanged. A placeholder left in the scheme or host is an error,
+// since dropping it would point the URL at a different site.
+func stripPlaceholders(rawURL string) (string, error) {
+	scheme, rest := "", rawURL
+	if i := strings.Index(rawURL, "://"); i >= 0 && !strings.ContainsAny(rawURL[:i], "/?#") {
+		scheme, rest = rawURL[:i+3], rawURL[i+3:]
 	}
+	rest, fragment, hasFragment := strings.Cut(rest, "#")
+	rest, query, hasQuery := strings.Cut(rest, "?")
 
-	parts := strings.Split(u.Path, "/")
-	var cleaned []string
-	for _, p := range parts {
-		if p == "" {
-			continue
+	// Without a scheme, a URL not starting with "/" still starts with its
+	// host, e.g. {env.url}/x.
+	host, path := "", rest
+	if scheme != "" || !strings.HasPrefix(rest, "/") {
+		host, path = rest, ""
+		if j := strings.Index(rest, "/"); j >= 0 {
+			host, path = rest[:j], rest[j:]
 		}
-		if strings.Contains(p, "{") && strings.Contains(p, "}") {
+	}
+	if hasPlaceholder(scheme) || hasPlaceholder(host) {
+		return "", fmt.Errorf("unresolved placeholder in host %q", scheme+host)
+	}
+
+	out := scheme + host + stripParts(path, "/", true)
+	if query = stripParts(query, "&", false); hasQuery && query != "" {
+		out += "?" + query
+	}
+	if hasFragment && !hasPlaceholder(fragment) {
+		out += "#" + fragment
+	}
+	return out, nil
+}
+
+// stripParts splits s on sep and drops empty parts and parts with
+// placeholders. With leading set, each kept part is prefixed with sep
+// (for paths); otherwise parts are joined with sep.
+func stripParts(s, sep string, leading bool) string {
+	var kept []string
+	for _, part := range strings.Split(s, sep) {
+		if part == "" || hasPlaceholder(part) {
 			continue
 		}
-		cleaned = append(cleaned, p)
+		kept = append(kept, part)
 	}
-
-	if len(cleaned) == 0 {
-		u.Path = ""
-	} else {
-		u.Path = "/" + strings.Join(cleaned, "/")
+	if len(kept) == 0 {
+		return ""
 	}
+	if leading {
+		return sep + strings.Join(kept, sep)
+	}
+	return strings.Join(kept, sep)
+}
 
-	return u.String()
+func hasPlaceholder(s string) bool {
+	return strings.Contains(s, "{") && strings.Contains(s, "}")
 }
@@ -1,6 +1,13 @@
 package resolve
 
-import "testing"
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/apermo/apermo-surf/internal/config"
+)
 
 func TestResolveExplicitArg_AutoPrefix(t *testing.T) {
 	tests := []struct {
@@ -33,6 +40,18 @@ func TestResolveExplicitArg_AutoPrefix(t *testing.T) {
 			pattern: "",
 			want:    "123",
 		},
+		{
+			name:    "hash number gets prefixed",
+			arg:     "#123",
+			pattern: `PROJ-\d+`,
+			want:    "PROJ-123",
+		},
+		{
+			name:    "hash number without pattern",
+			arg:     "#42",
+			pattern: "",
+			want:    "42",
+		},
 		{
 			name:    "pattern without literal prefix",
 			arg:     "123",
@@ -72,7 +91,7 @@ func TestExtractLiteralPrefix(t *testing.T) {
 	}
 }
 
-func TestStripPlaceholderSegment(t *testing.T) {
+func TestStripPlaceholders(t *testing.T) {
 	tests := []struct {
 		name string
 		url  string
@@ -103,14 +122,558 @@ func TestStripPlaceholderSegment(t *testing.T) {
 			url:  "https://example.com/browse/{ticket}?view=board",
 			want: "https://example.com/browse?view=board",
 		},
+		{
+			name: "keeps encoded segments",
+			url:  "https://example.com/tree/fix%2F%2312/{ticket}",
+			want: "https://example.com/tree/fix%2F%2312",
+		},
+		{
+			name: "removes query parameter",
+			url:  "https://example.com/issues?project=shop&assignee={vars.me}&sort=asc",
+			want: "https://example.com/issues?project=shop&sort=asc",
+		},
+		{
+			name: "drops empty query",
+			url:  "https://example.com/issues?q={query}",
+			want: "https://example.com/issues",
+		},
+		{
+			name: "removes fragment",
+			url:  "https://example.com/docs#{ticket}",
+			want: "https://example.com/docs",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := stripPlaceholderSegment(tt.url)
+			got, err := stripPlaceholders(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
 			if got != tt.want {
 				t.Errorf("got %q, want %q", got, tt.want)
 			}
 		})
 	}
 }
+
+func TestStripPlaceholders_Host(t *testing.T) {
+	for _, rawURL := range []string{
+		"https://{branch|slug}.preview.example.com/login",
+		"https://{host}/x",
+		"{env.url}/x",
+	} {
+		if got, err := stripPlaceholders(rawURL); err == nil {
+			t.Errorf("stripPlaceholders(%q) = %q, want error", rawURL, got)
+		}
+	}
+}
+
+func TestResolveWith_MissingHost(t *testing.T) {
+	link := config.Link{URL: "https://{vars.missing}.preview.example.com/login"}
+	if got, err := ResolveWith(link, Options{Dir: t.TempDir()}); err == nil {
+		t.Errorf("URL = %q, want error instead of a rewritten host", got.URL)
+	}
+
+	link.FallbackURL = "https://example.com"
+	got, err := ResolveWith(link, Options{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.URL != "https://example.com" {
+		t.Errorf("URL = %q, want the fallback", got.URL)
+	}
+}
+
+func TestResolveWith_EnvPlaceholders(t *testing.T) {
+	environments := map[string]config.Link{
+		"prod":    {URL: "https://example.com", Vars: map[string]string{"sentry_env": "production"}},
+		"staging": {URL: "https://staging.example.com"},
+	}
+	link := config.Link{URL: "https://sentry.io/{env.name}/{env.sentry_env}?host={env.url}"}
+
+	tests := []struct {
+		name     string
+		args     []string
+		want     string
+		warnings int
+	}{
+		{
+			name: "default environment",
+			want: "https://sentry.io/prod/production?host=https://example.com",
+		},
+		{
+			name:     "environment from arg, missing var stripped",
+			args:     []string{"stag"},
+			want:     "https://sentry.io/staging?host=https://staging.example.com",
+			warnings: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := ArgOptions(&config.Config{Environments: environments}, link, t.TempDir(), tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _ := ResolveWith(link, opts)
+			if got.URL != tt.want {
+				t.Errorf("URL = %q, want %q", got.URL, tt.want)
+			}
+			if len(got.Warnings) != tt.warnings {
+				t.Errorf("warnings = %v, want %d", got.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestEnvArg(t *testing.T) {
+	environments := map[string]config.Link{
+		"prod":    {URL: "https://example.com"},
+		"staging": {URL: "https://staging.example.com"},
+		"stage-2": {URL: "https://stage-2.example.com"},
+	}
+
+	tests := []struct {
+		name     string
+		url      string
+		args     []string
+		wantEnv  string
+		wantRest int
+	}{
+		{"unique prefix", "https://sentry.io/?e={env.name}", []string{"pr", "123"}, "prod", 1},
+		{"ambiguous prefix kept as arg", "https://sentry.io/?e={env.name}", []string{"st"}, "prod", 1},
+		{"fuzzy match kept as arg", "https://sentry.io/?e={env.name}", []string{"stg"}, "prod", 1},
+		{"exact, case-insensitive", "https://sentry.io/?e={env.name}", []string{"Staging"}, "staging", 0},
+		{"no env placeholder", "https://jira.example.com/{ticket}", []string{"staging"}, "", 1},
+		{"env consumed", "https://sentry.io/?e={env.name}", []string{"staging", "123"}, "staging", 1},
+		{"ticket kept, default env", "https://sentry.io/?e={env.name}", []string{"123"}, "prod", 1},
+		{"no args, default env", "https://sentry.io/?e={env.name}", nil, "prod", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, rest := EnvArg(config.Link{URL: tt.url}, environments, tt.args)
+			if env != tt.wantEnv {
+				t.Errorf("env = %q, want %q", env, tt.wantEnv)
+			}
+			if len(rest) != tt.wantRest {
+				t.Errorf("rest = %v, want %d args", rest, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestResolveWith_Filters(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name   string
+		url    string
+		ticket string
+		want   string
+	}{
+		{"default when unresolved", "https://jira.example.com/{ticket|default:BOARD}", "", "https://jira.example.com/BOARD"},
+		{"slug", "https://{ticket|slug}.preview.example.com", "Fix/Login Page", "https://fix-login-page.preview.example.com"},
+		{"urlencode", "https://example.com/search?q={ticket|urlencode}", "a&b #1", "https://example.com/search?q=a%26b%20%231"},
+		{"upper", "https://example.com/{ticket|upper}", "proj-1", "https://example.com/PROJ-1"},
+		{"escaped in path", "https://example.com/t/{ticket}", "feature/#12 fix", "https://example.com/t/feature/%2312%20fix"},
+		{"escaped in query", "https://example.com/?t={ticket}", "a&b #1", "https://example.com/?t=a%26b+%231"},
+		{"raw", "https://example.com/{ticket|raw}", "a/b?c=1", "https://example.com/a/b?c=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := ResolveWith(config.Link{URL: tt.url}, Options{Dir: dir, Ticket: tt.ticket})
+			if got.URL != tt.want {
+				t.Errorf("URL = %q, want %q", got.URL, tt.want)
+			}
+			if len(got.Warnings) != 0 {
+				t.Errorf("unexpected warnings: %v", got.Warnings)
+			}
+		})
+	}
+}
+
+func TestResolveWith_GitMetadata(t *testing.T) {
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"commit", "-q", "--allow-empty", "-m", "init"},
+		{"tag", "v1.0.0"},
+		{"remote", "add", "origin", "git@github.com:acme/shop.git"},
+	} {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	got, _ := ResolveWith(config.Link{URL: "https://{host}/{owner}/{repo}/releases/tag/{tag}"}, Options{Dir: dir})
+	if got.URL != "https://github.com/acme/shop/releases/tag/v1.0.0" {
+		t.Errorf("URL = %q", got.URL)
+	}
+
+	got, _ = ResolveWith(config.Link{URL: "https://ci.example.com/{owner}/builds/{commit}"}, Options{Dir: dir})
+	if len(got.URL) != len("https://ci.example.com/acme/builds/")+40 {
+		t.Errorf("URL = %q, want full commit SHA", got.URL)
+	}
+}
+
+func TestResolveWith_ProcessEnv(t *testing.T) {
+	t.Setenv("SURF_TEST_PROJECT", "shop")
+	got, _ := ResolveWith(config.Link{URL: "https://{env:SURF_TEST_PROJECT}.ddev.site"}, Options{Dir: t.TempDir()})
+	if got.URL != "https://shop.ddev.site" {
+		t.Errorf("URL = %q", got.URL)
+	}
+}
+
+func TestResolveWith_ProcessEnvNeedsTrust(t *testing.T) {
+	t.Setenv("SURF_TEST_TOKEN", "secret")
+	link := config.Link{URL: "https://example.com/?t={env:SURF_TEST_TOKEN}", Source: "/repo/.surf-links.yml"}
+
+	got, _ := ResolveWith(link, Options{Dir: t.TempDir()})
+	if got.URL != "https://example.com" || len(got.Warnings) == 0 || !strings.Contains(got.Warnings[0], "not trusted") {
+		t.Errorf("untrusted file: URL = %q, warnings = %v", got.URL, got.Warnings)
+	}
+
+	trusted := func(path string) bool { return path == link.Source }
+	got, _ = ResolveWith(link, Options{Dir: t.TempDir(), Trusted: trusted})
+	if got.URL != "https://example.com/?t=secret" {
+		t.Errorf("trusted file: URL = %q", got.URL)
+	}
+}
+
+func TestResolveWith_Vars(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, config.FileName)
+	vars := map[string]config.Var{
+		"team": {Value: "payments"},
+		"ns":   {Command: "echo dev-ns", Source: source},
+	}
+	link := config.Link{URL: "https://k8s.example.com/{vars.team}/{vars.ns}"}
+
+	tests := []struct {
+		name     string
+		trusted  func(string) bool
+		want     string
+		warnings int
+	}{
+		// Not trusted: one warning for the command, one for the placeholder
+		{"untrusted command skipped", nil, "https://k8s.example.com/payments", 2},
+		{"trusted command runs", func(path string) bool { return path == source }, "https://k8s.example.com/payments/dev-ns", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := ResolveWith(link, Options{Dir: dir, Vars: vars, Trusted: tt.trusted})
+			if got.URL != tt.want {
+				t.Errorf("URL = %q, want %q", got.URL, tt.want)
+			}
+			if len(got.Warnings) != tt.warnings {
+				t.Errorf("warnings = %v", got.Warnings)
+			}
+		})
+	}
+}
+
+func TestResolveWith_EscapesValues(t *testing.T) {
+	t.Setenv("SURF_TEST_SITE", "a&b")
+	opts := Options{
+		Dir:     t.TempDir(),
+		EnvName: "prod",
+		Env:     config.Link{URL: "https://example.com/app?x=1", Vars: map[string]string{"team": "ops #1"}},
+		Vars:    map[string]config.Var{"ns": {Value: "a b/c"}},
+	}
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"vars in path", "https://k8s.example.com/{vars.ns}", "https://k8s.example.com/a%20b/c"},
+		{"env var in query", "https://sentry.io/?team={env.team}", "https://sentry.io/?team=ops+%231"},
+		{"process env in query", "https://example.com/?site={env:SURF_TEST_SITE}", "https://example.com/?site=a%26b"},
+		{"env url as is", "{env.url}#top", "https://example.com/app?x=1#top"},
+		{"raw", "https://example.com/{env.team|raw}", "https://example.com/ops #1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := ResolveWith(config.Link{URL: tt.url}, opts)
+			if got.URL != tt.want {
+				t.Errorf("URL = %q, want %q", got.URL, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveWith_GlobalVarCommand(t *testing.T) {
+	vars := map[string]config.Var{"user": {Command: "echo alice"}}
+	got, _ := ResolveWith(config.Link{URL: "https://example.com/{vars.user}"}, Options{Dir: t.TempDir(), Vars: vars})
+	if got.URL != "https://example.com/alice" {
+		t.Errorf("URL = %q, want global var command to run", got.URL)
+	}
+}
+
+func TestResolveWith_Params(t *testing.T) {
+	link := config.Link{
+		URL: "https://admin.example.com/customers/{customer}/orders/{order_id}",
+		Params: map[string]config.Param{
+			"order_id": {Pattern: `\d+`, Prompt: "Order ID"},
+			"customer": {},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		opts    Options
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "positional in URL order",
+			opts: Options{Params: []string{"acme", "4711"}},
+			want: "https://admin.example.com/customers/acme/orders/4711",
+		},
+		{
+			name: "missing param stripped without prompt",
+			opts: Options{Params: []string{"acme"}},
+			want: "https://admin.example.com/customers/acme/orders",
+		},
+		{
+			name: "missing param prompted",
+			opts: Options{Params: []string{"acme"}, Prompt: func(label string) (string, error) {
+				if label != "Order ID" {
+					t.Errorf("prompt label = %q", label)
+				}
+				return "42", nil
+			}},
+			want: "https://admin.example.com/customers/acme/orders/42",
+		},
+		{
+			name: "escaped in path",
+			opts: Options{Params: []string{"a b#c?d", "4711"}},
+			want: "https://admin.example.com/customers/a%20b%23c%3Fd/orders/4711",
+		},
+		{
+			name:    "pattern mismatch",
+			opts:    Options{Params: []string{"acme", "abc"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Dir = t.TempDir()
+			got, err := ResolveWith(link, tt.opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %q", got.URL)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.URL != tt.want {
+				t.Errorf("URL = %q, want %q", got.URL, tt.want)
+			}
+		})
+	}
+}
+
+func TestArgOptions_Params(t *testing.T) {
+	link := config.Link{
+		URL:    "https://admin.example.com/orders/{order_id}?ref={ticket}",
+		Params: map[string]config.Param{"order_id": {}},
+	}
+	cfg := &config.Config{}
+
+	opts, err := ArgOptions(cfg, link, "", []string{"4711", "PROJ-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opts.Params) != 1 || opts.Params[0] != "4711" || opts.Ticket != "PROJ-1" {
+		t.Errorf("opts = %+v", opts)
+	}
+
+	if _, err := ArgOptions(cfg, link, "", []string{"4711", "PROJ-1", "extra"}); err == nil {
+		t.Error("expected error for surplus args")
+	}
+}
+
+func TestResolveWith_Search(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://wiki.example.com/dosearchsite.action?text={query}", "https://wiki.example.com/dosearchsite.action?text=cache+invalidation+%26+more%2Fless"},
+		{"https://wiki.example.com/search/{query}", "https://wiki.example.com/search/cache%20invalidation%20%26%20more%2Fless"},
+		{"https://wiki.example.com/search/{query|raw}", "https://wiki.example.com/search/cache invalidation & more/less"},
+		{"https://wiki.example.com/search?q={query|urlencode}", "https://wiki.example.com/search?q=cache%20invalidation%20%26%20more%2Fless"},
+		{"https://wiki.example.com/search#{query}", "https://wiki.example.com/search#cache+invalidation+%26+more%2Fless"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			link := config.Link{URL: tt.url}
+			opts, err := ArgOptions(&config.Config{}, link, t.TempDir(), []string{"cache", "invalidation", "&", "more/less"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ResolveWith(link, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.URL != tt.want {
+				t.Errorf("URL = %q, want %q", got.URL, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveWith_OnMissing(t *testing.T) {
+	url := "https://jira.example.com/browse/{ticket}?focus={vars.me}"
+	vars := map[string]config.Var{"me": {Value: "alice"}}
+
+	tests := []struct {
+		name    string
+		link    config.Link
+		prompt  func(string) (string, error)
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "strip by default",
+			link: config.Link{URL: url},
+			want: "https://jira.example.com/browse?focus=alice",
+		},
+		{
+			name: "fallback",
+			link: config.Link{URL: url, OnMissing: config.OnMissingFallback, FallbackURL: "https://jira.example.com/board?me={vars.me}"},
+			want: "https://jira.example.com/board?me=alice",
+		},
+		{
+			name:    "error",
+			link:    config.Link{URL: url, OnMissing: config.OnMissingError},
+			wantErr: true,
+		},
+		{
+			name:   "prompt",
+			link:   config.Link{URL: url, OnMissing: config.OnMissingPrompt, Pattern: `PROJ-\d+`},
+			prompt: func(label string) (string, error) { return "PROJ-7", nil },
+			want:   "https://jira.example.com/browse/PROJ-7?focus=alice",
+		},
+		{
+			name: "prompt without terminal strips",
+			link: config.Link{URL: url, OnMissing: config.OnMissingPrompt},
+			want: "https://jira.example.com/browse?focus=alice",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveWith(tt.link, Options{Dir: t.TempDir(), Vars: vars, Prompt: tt.prompt})
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %q", got.URL)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.URL != tt.want {
+				t.Errorf("URL = %q, want %q", got.URL, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveWith_Query(t *testing.T) {
+	link := config.Link{
+		URL: "https://sentry.io/organizations/acme/issues/?project=1#list",
+		Query: map[string]string{
+			"environment": "{env.sentry_env}",
+			"query":       "release:{vars.release} is:unresolved",
+			"assignee":    "{vars.missing}",
+		},
+	}
+	opts := Options{
+		Dir:  t.TempDir(),
+		Env:  config.Link{Vars: map[string]string{"sentry_env": "prod & eu"}},
+		Vars: map[string]config.Var{"release": {Value: "v1.2/rc"}},
+	}
+
+	got, err := ResolveWith(link, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "https://sentry.io/organizations/acme/issues?project=1&environment=prod%20%26%20eu&query=release%3Av1.2%2Frc%20is%3Aunresolved#list"
+	if got.URL != want {
+		t.Errorf("URL = %q\nwant  %q", got.URL, want)
+	}
+	if len(got.Warnings) != 1 {
+		t.Errorf("warnings = %v, want one for {vars.missing}", got.Warnings)
+	}
+}
+
+func TestQueryTemplate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"is:open {query}", "is%3Aopen%20{query|urlencode}"},
+		{"{query|urlencode}", "{query|urlencode}"},
+		{"{query|raw}", "{query|raw}"},
+		{"{query|raw|lower}", "{query|raw|lower}"},
+	}
+	for _, tt := range tests {
+		if got := queryTemplate(tt.value); got != tt.want {
+			t.Errorf("queryTemplate(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestArgOptions_DeepLink(t *testing.T) {
+	cfg := &config.Config{Environments: map[string]config.Link{
+		"prod":  {URL: "https://example.com/"},
+		"local": {URL: "https://shop.ddev.site"},
+	}}
+
+	tests := []struct {
+		name string
+		link config.Link
+		args []string
+		want string
+	}{
+		{"path and query", cfg.Environments["prod"], []string{"/wp-admin/plugins.php?plugin_status=active"}, "https://example.com/wp-admin/plugins.php?plugin_status=active"},
+		{"query only", cfg.Environments["local"], []string{"?s=shoes"}, "https://shop.ddev.site?s=shoes"},
+		{"fragment", config.Link{URL: "https://docs.example.com/guide#intro"}, []string{"#setup"}, "https://docs.example.com/guide#setup"},
+		{"with ticket", config.Link{URL: "https://jira.example.com/browse/{ticket}"}, []string{"/comments", "PROJ-1"}, "https://jira.example.com/browse/PROJ-1/comments"},
+		{"url in query", cfg.Environments["prod"], []string{"/cb?u=https://x.example.com"}, "https://example.com/cb?u=https://x.example.com"},
+		{"hash ticket", config.Link{URL: "https://github.com/acme/shop/issues/{ticket}"}, []string{"#123"}, "https://github.com/acme/shop/issues/123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := ArgOptions(cfg, tt.link, t.TempDir(), tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ResolveWith(tt.link, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.URL != tt.want {
+				t.Errorf("URL = %q, want %q", got.URL, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeepLinkArg_MoreThanOne(t *testing.T) {
+	if _, _, err := DeepLinkArg([]string{"/a", "?b=1"}, true); err == nil {
+		t.Error("expected error for two deep links")
+	}
+}
@@ -0,0 +1,75 @@
+package reverse
+
+import (
+	"sort"
+	"strings"
+)
+
+// Candidate is a link with its resolved URL.
+type Candidate struct {
+	Name      string
+	Qualified string
+	Generated bool
+	URL       string
+}
+
+// Match is the candidate a URL belongs to. Rest is the part of the URL
+// after the candidate's URL, starting with "/", "?", or "#" (or empty for
+// an exact match). A trailing slash of the URL is kept in Rest.
+type Match struct {
+	Candidate
+	Rest string
+}
+
+// Find returns the candidate whose URL is the longest prefix of rawURL.
+// Prefixes only match at a path, query, or fragment boundary, so
+// https://example.com does not match https://example.community. The scheme
+// and host case are ignored. On equal length, explicit links win over
+// generated ones, then shorter names.
+func Find(candidates []Candidate, rawURL string) (Match, bool) {
+	target := normalizeHost(rawURL)
+
+	sorted := append([]Candidate{}, candidates...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if la, lb := len(normalize(a.URL)), len(normalize(b.URL)); la != lb {
+			return la > lb
+		}
+		if a.Generated != b.Generated {
+			return !a.Generated
+		}
+		return a.Name < b.Name
+	})
+
+	for _, c := range sorted {
+		prefix := normalize(c.URL)
+		if prefix == "" || !strings.HasPrefix(target, prefix) {
+			continue
+		}
+		rest := target[len(prefix):]
+		if rest == "" || strings.ContainsAny(rest[:1], "/?#") {
+			return Match{Candidate: c, Rest: rest}, true
+		}
+	}
+	return Match{}, false
+}
+
+// normalize is normalizeHost with a trailing slash trimmed, so candidate
+// URLs with and without one match the same prefixes.
+func normalize(rawURL string) string {
+	return strings.TrimSuffix(normalizeHost(rawURL), "/")
+}
+
+// normalizeHost drops the scheme and lowercases the host, leaving the path,
+// query, and fragment untouched.
+func normalizeHost(rawURL string) string {
+	s := strings.TrimSpace(rawURL)
+	if i := strings.Index(s, "://"); i > 0 && !strings.ContainsAny(s[:i], "/?#") {
+		s = s[i+len("://"):]
+	}
+	host, path := s, ""
+	if i := strings.IndexAny(s, "/?#"); i >= 0 {
+		host, path = s[:i], s[i:]
+	}
+	return strings.ToLower(host) + path
+}
